@@ -1,25 +1,27 @@
 package auth
 
-import (
-	"errors"
+import "errors"
 
-	"golang.org/x/crypto/bcrypt"
-)
+// MaxPasswordLen is the maximum password length in bytes, as bcrypt ignores anything past it
+const MaxPasswordLen = 72
 
 var (
-	roles map[string][]string
+	// DefaultRegistry holds the roles of the accounts without a registry of their own
+	DefaultRegistry = NewRegistry()
 
 	ErrInvalidUserName = errors.New("invalid username")
 	ErrInvalidPassword = errors.New("invalid password")
+	ErrPasswordTooLong = errors.New("password longer than 72 bytes")
 )
 
-func init() {
-	roles = make(map[string][]string, 1)
+// RegisterRole registers a new role and its capabilities in DefaultRegistry
+func RegisterRole(role string, capabilities ...string) {
+	DefaultRegistry.Register(role, capabilities...)
 }
 
-// RegisterRole registers a new role and its capabilities
-func RegisterRole(role string, capabilities ...string) {
-	roles[role] = capabilities
+// InheritRole sets the roles a role inherits the capabilities from in DefaultRegistry
+func InheritRole(role string, parents ...string) error {
+	return DefaultRegistry.Inherit(role, parents...)
 }
 
 // Auth handles user authentication and authorization
@@ -27,6 +29,15 @@ type Auth struct {
 	Username string   `json:"username"`
 	Password []byte   `json:"-"`
 	Roles    []string `json:"roles,omitempty"`
+
+	// Attrs are attributes of the account, like its tenant, for the policies
+	Attrs map[string]string `json:"attrs,omitempty"`
+
+	// Registry holds the capabilities of the roles. If nil, DefaultRegistry is used
+	Registry *Registry `json:"-"`
+
+	// Hasher hashes the new passwords. If nil, DefaultHasher is used
+	Hasher Hasher `json:"-"`
 }
 
 // New returns a Auth object
@@ -34,9 +45,16 @@ func New() *Auth {
 	return &Auth{}
 }
 
-// GeneratePassword generates a hashed password for the user account, using BCrypt algorithm
+// GeneratePassword generates a hashed password for the user account, using the
+// hasher of the account. With bcrypt, passwords longer than MaxPasswordLen
+// fail with ErrPasswordTooLong
 func (a *Auth) GeneratePassword(password []byte) error {
-	hash, err := bcrypt.GenerateFromPassword(password, bcrypt.DefaultCost)
+	return a.GeneratePasswordHasher(password, a.hasher())
+}
+
+// GeneratePasswordHasher generates a hashed password using the given hasher
+func (a *Auth) GeneratePasswordHasher(password []byte, h Hasher) error {
+	hash, err := h.Hash(password)
 	if err != nil {
 		return err
 	}
@@ -44,6 +62,11 @@ func (a *Auth) GeneratePassword(password []byte) error {
 	return nil
 }
 
+// GeneratePasswordCost generates a hashed password using BCrypt algorithm with the given cost
+func (a *Auth) GeneratePasswordCost(password []byte, cost int) error {
+	return a.GeneratePasswordHasher(password, &BcryptHasher{Cost: cost})
+}
+
 // SetUserName sets the username
 func (a *Auth) SetUserName(username string) {
 	a.Username = username
@@ -60,9 +83,47 @@ func (a *Auth) Check(username string, password []byte) error {
 	return nil
 }
 
-// CheckPassword checks the password is correct
+// CheckPassword checks the password is correct, with the algorithm the stored
+// hash was generated with: the hasher of the account if it is the same, or
+// the one registered with RegisterHasher. Wrong passwords fail with
+// ErrInvalidPassword
 func (a *Auth) CheckPassword(password []byte) error {
-	return bcrypt.CompareHashAndPassword(a.Password, password)
+	return verify(a.hasher(), a.Password, password)
+}
+
+// NeedsRehash tells whether the stored hash was generated with another
+// algorithm than the hasher of the account, or with weaker parameters, and
+// should be generated again
+func (a *Auth) NeedsRehash() bool {
+	return a.hasher().NeedsRehash(a.Password)
+}
+
+func (a *Auth) hasher() Hasher {
+	if a.Hasher != nil {
+		return a.Hasher
+	}
+	return DefaultHasher
+}
+
+// CheckPasswordRehash checks the password, generating the hash again if it
+// needs rehashing, as a login can do to upgrade old hashes. It tells whether
+// the hash was changed, in which case the account should be saved
+func (a *Auth) CheckPasswordRehash(password []byte) (bool, error) {
+	if err := a.CheckPassword(password); err != nil {
+		return false, err
+	}
+	if !a.NeedsRehash() {
+		return false, nil
+	}
+	err := a.GeneratePassword(password)
+	if err == ErrPasswordTooLong {
+		// a truncated bcrypt password can't be hashed again with bcrypt
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 // AddRole adds the given role string to the account
@@ -80,7 +141,17 @@ func (a *Auth) HasRole(r string) bool {
 	return false
 }
 
-// Can checks if the user has ANY of the given capabilities
+// Can checks if the user has ANY of the given capabilities. It has no
+// resource to check the policies with, so the capabilities restricted by a
+// policy are denied, and need to be checked with Authorize.
+//
+// Capabilities are hierarchical names, with segments separated by ':' or '.',
+// like "posts:edit:own" or "billing.invoices.read". The capabilities of the
+// roles may be patterns: a "*" segment matches any single segment, and a
+// trailing "*" matches one or more segments with the same separator, so
+// "posts:edit:*" implies "posts:edit:own" but not "posts:edit:own.title",
+// and "*" implies every capability. Capabilities denied with DenyPrefix are
+// never granted
 func (a *Auth) Can(capabilities ...string) bool {
 	for _, c := range capabilities {
 		if a.can(c) {
@@ -101,19 +172,12 @@ func (a *Auth) CanAll(capabilities ...string) bool {
 }
 
 func (a *Auth) can(capability string) bool {
-	for _, role := range a.Roles {
-		for _, c := range roleCapabilities(role) {
-			if c == capability {
-				return true
-			}
-		}
-	}
-	return false
+	return a.Authorize(capability, nil).Allowed
 }
 
-func roleCapabilities(role string) []string {
-	if roles[role] != nil {
-		return roles[role]
+func (a *Auth) registry() *Registry {
+	if a.Registry != nil {
+		return a.Registry
 	}
-	return []string{}
+	return DefaultRegistry
 }
@@ -1,9 +1,11 @@
 package auth_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/syb-devs/gotools/auth"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestCheck(t *testing.T) {
@@ -73,3 +75,54 @@ func TestCan(t *testing.T) {
 		t.Error("are you saying a dev/sysadmin can travel in time and fly? seriously?? ")
 	}
 }
+
+func TestRehash(t *testing.T) {
+	a := auth.New()
+	plain := []byte("7h1$ 1$ 50m37h!n6")
+	if err := a.GeneratePasswordCost(plain, bcrypt.MinCost); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !a.NeedsRehash() {
+		t.Error("a hash with the minimum cost should need rehashing")
+	}
+
+	if _, err := a.CheckPasswordRehash([]byte("invalid password")); err == nil {
+		t.Error("expecting an error for an invalid password")
+	}
+	rehashed, err := a.CheckPasswordRehash(plain)
+	if err != nil || !rehashed {
+		t.Fatalf("expecting the password to be rehashed, got %v, %v", rehashed, err)
+	}
+	if cost, _ := bcrypt.Cost(a.Password); cost != bcrypt.DefaultCost {
+		t.Errorf("expecting cost %d, got %d", bcrypt.DefaultCost, cost)
+	}
+	if rehashed, err := a.CheckPasswordRehash(plain); err != nil || rehashed {
+		t.Errorf("expecting no rehash, got %v, %v", rehashed, err)
+	}
+}
+
+func TestLongPassword(t *testing.T) {
+	a := auth.New()
+	long := []byte(strings.Repeat("x", auth.MaxPasswordLen+1))
+	if err := a.GeneratePassword(long); err != auth.ErrPasswordTooLong {
+		t.Errorf("expecting password too long error, got %v", err)
+	}
+
+	if err := a.GeneratePasswordCost(long[:auth.MaxPasswordLen], bcrypt.MinCost); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := a.CheckPassword(long); err != auth.ErrPasswordTooLong {
+		t.Errorf("expecting password too long error, got %v", err)
+	}
+	a.Hasher = &auth.BcryptHasher{TruncateLong: true}
+	if err := a.CheckPassword(long); err != nil {
+		t.Errorf("expecting a truncated password to match, got %v", err)
+	}
+	if rehashed, err := a.CheckPasswordRehash(long); err != nil || rehashed {
+		t.Errorf("expecting no rehash of a truncated password, got %v, %v", rehashed, err)
+	}
+	b := &auth.Auth{Password: a.Password}
+	if err := b.CheckPassword(long); err != auth.ErrPasswordTooLong {
+		t.Errorf("expecting password too long error for other accounts, got %v", err)
+	}
+}
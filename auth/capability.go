@@ -0,0 +1,48 @@
+package auth
+
+import "strings"
+
+// DenyPrefix marks the role capabilities that are denied instead of granted,
+// like "!posts:delete:*". Denials override the grants of every role of the account
+const DenyPrefix = "!"
+
+// matchCapability tells whether a granted capability pattern implies the
+// capability. A trailing "*" only matches segments separated as the pattern
+// is, so "billing.*" doesn't imply "billing.x:y", while a lone "*" implies
+// any capability
+func matchCapability(pattern, capability string) bool {
+	if pattern == capability {
+		return true
+	}
+	var sep byte
+	for {
+		pseg, psep, prest := cutSegment(pattern)
+		cseg, csep, crest := cutSegment(capability)
+		switch {
+		case pseg == "*" && psep == 0:
+			return capability != "" && (sep == 0 || !strings.ContainsAny(capability, otherSeparator(sep)))
+		case pseg != "*" && pseg != cseg, psep != csep:
+			return false
+		case psep == 0:
+			return true
+		}
+		pattern, capability, sep = prest, crest, psep
+	}
+}
+
+func otherSeparator(sep byte) string {
+	if sep == ':' {
+		return "."
+	}
+	return ":"
+}
+
+// cutSegment returns the first segment of a capability, the separator after
+// it, zero if it is the last one, and the rest of the capability
+func cutSegment(capability string) (string, byte, string) {
+	i := strings.IndexAny(capability, ":.")
+	if i < 0 {
+		return capability, 0, ""
+	}
+	return capability[:i], capability[i], capability[i+1:]
+}
@@ -0,0 +1,59 @@
+package auth_test
+
+import (
+	"testing"
+
+	"github.com/syb-devs/gotools/auth"
+)
+
+func TestCapabilityPatterns(t *testing.T) {
+	r := auth.NewRegistry()
+	r.Register("writer", "posts:edit:*", "posts:*:draft", "billing.*", "comments")
+	r.Register("intern", "!posts:edit:published", "!billing.refunds.*")
+	r.Register("root", "*")
+
+	tests := []struct {
+		roles      []string
+		capability string
+		want       bool
+	}{
+		{[]string{"writer"}, "posts:edit:own", true},
+		{[]string{"writer"}, "posts:edit:any:field", true},
+		{[]string{"writer"}, "posts:edit", false},
+		{[]string{"writer"}, "posts:delete:draft", true},
+		{[]string{"writer"}, "posts:delete:published", false},
+		{[]string{"writer"}, "billing.invoices.read", true},
+		{[]string{"writer"}, "billing:invoices", false},
+		{[]string{"writer"}, "billing.invoices:read", false},
+		{[]string{"writer"}, "posts:edit:any.field", false},
+		{[]string{"writer"}, "comments", true},
+		{[]string{"writer"}, "comments:delete", false},
+		{[]string{"writer", "intern"}, "posts:edit:own", true},
+		{[]string{"writer", "intern"}, "posts:edit:published", false},
+		{[]string{"writer", "intern"}, "billing.refunds.create", false},
+		{[]string{"writer", "intern"}, "billing.invoices.read", true},
+		{[]string{"intern"}, "posts:edit:own", false},
+		{[]string{"root"}, "anything:at.all", true},
+		{[]string{"root", "intern"}, "posts:edit:published", false},
+	}
+	for i, tc := range tests {
+		a := &auth.Auth{Roles: tc.roles, Registry: r}
+		if have := a.Can(tc.capability); have != tc.want {
+			t.Errorf("#%d: %v can %s: have %v, want %v", i, tc.roles, tc.capability, have, tc.want)
+		}
+	}
+}
+
+func TestInheritedDeny(t *testing.T) {
+	r := auth.NewRegistry()
+	r.Register("restricted", "!posts:delete:*")
+	r.Register("editor", "posts:*")
+	if err := r.Inherit("editor", "restricted"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	a := &auth.Auth{Roles: []string{"editor"}, Registry: r}
+	if !a.Can("posts:edit:own") || a.Can("posts:delete:own") {
+		t.Error("the editor should inherit the denied capabilities")
+	}
+}
@@ -0,0 +1,526 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+var (
+	// ErrUnknownHash is returned when the stored hash has an unknown format
+	ErrUnknownHash = errors.New("unknown password hash format")
+
+	// DefaultHasher hashes the new passwords of the accounts without a hasher
+	// of their own. Stored hashes generated by any other algorithm, or with
+	// weaker parameters, are reported by NeedsRehash
+	DefaultHasher Hasher = &BcryptHasher{}
+)
+
+// Hasher hashes passwords into self-describing strings, which tell the
+// algorithm and parameters used. They follow the PHC string format, like
+// $argon2id$v=19$m=65536,t=3,p=4$salt$hash, except for bcrypt, which keeps
+// its own $2a$10$... format
+type Hasher interface {
+	// Hash returns the hash of the password, with a random salt
+	Hash(password []byte) ([]byte, error)
+
+	// Verify checks the password against a hash generated by the same
+	// algorithm, whatever its parameters, failing with ErrInvalidPassword.
+	// Hashes of other algorithms fail with ErrUnknownHash
+	Verify(hash, password []byte) error
+
+	// NeedsRehash tells whether the hash was not generated by the hasher's
+	// algorithm, or was generated with weaker parameters
+	NeedsRehash(hash []byte) bool
+}
+
+var (
+	hashersMu sync.RWMutex
+	// hashers verifies the stored hashes, by the algorithm id of their format
+	hashers = map[string]Hasher{
+		"2a":            &BcryptHasher{},
+		"2b":            &BcryptHasher{},
+		"2y":            &BcryptHasher{},
+		"argon2id":      &Argon2idHasher{},
+		"scrypt":        &ScryptHasher{},
+		"pbkdf2-sha256": &PBKDF2Hasher{},
+		"pbkdf2-sha512": &PBKDF2Hasher{},
+	}
+)
+
+// RegisterHasher registers the hasher verifying the stored hashes with the
+// algorithm id, the first $ separated field of their format, so that
+// CheckPassword can check them. It replaces the hasher registered for the
+// id, and panics if the hasher is nil
+func RegisterHasher(id string, h Hasher) {
+	if h == nil {
+		panic("auth: nil hasher for " + id)
+	}
+	hashersMu.Lock()
+	hashers[id] = h
+	hashersMu.Unlock()
+}
+
+// verify checks the password against the hash with the given hasher, or
+// with the one registered for its algorithm if it has another one
+func verify(h Hasher, hash, password []byte) error {
+	err := h.Verify(hash, password)
+	if !errors.Is(err, ErrUnknownHash) {
+		return err
+	}
+	hashersMu.RLock()
+	h, ok := hashers[hashID(hash)]
+	hashersMu.RUnlock()
+	if !ok {
+		return ErrUnknownHash
+	}
+	return h.Verify(hash, password)
+}
+
+// hashID returns the algorithm id of a hash, the first $ separated field
+func hashID(hash []byte) string {
+	fields := strings.SplitN(string(hash), "$", 3)
+	if len(fields) < 3 || fields[0] != "" {
+		return ""
+	}
+	return fields[1]
+}
+
+// BcryptHasher hashes passwords with bcrypt, which only takes the first
+// MaxPasswordLen bytes into account, so longer passwords are rejected
+type BcryptHasher struct {
+	// Cost is the bcrypt cost of the new hashes. Stored hashes with a lower
+	// cost need rehashing. If zero, bcrypt.DefaultCost is used
+	Cost int
+
+	// TruncateLong makes Verify compare only the first MaxPasswordLen bytes
+	// of longer passwords, which is needed to check hashes generated before
+	// long passwords were rejected. Otherwise they fail with ErrPasswordTooLong.
+	// Set it on the hasher of the accounts, or register such a hasher for the
+	// bcrypt ids 2a, 2b and 2y
+	TruncateLong bool
+}
+
+func (h *BcryptHasher) cost() int {
+	if h.Cost == 0 {
+		return bcrypt.DefaultCost
+	}
+	return h.Cost
+}
+
+// Hash returns the bcrypt hash of the password
+func (h *BcryptHasher) Hash(password []byte) ([]byte, error) {
+	if len(password) > MaxPasswordLen {
+		return nil, ErrPasswordTooLong
+	}
+	return bcrypt.GenerateFromPassword(password, h.cost())
+}
+
+// Verify checks the password against a bcrypt hash. Passwords longer than
+// MaxPasswordLen fail with ErrPasswordTooLong, unless TruncateLong is set
+func (h *BcryptHasher) Verify(hash, password []byte) error {
+	if id := hashID(hash); id != "2a" && id != "2b" && id != "2y" {
+		return ErrUnknownHash
+	}
+	if len(password) > MaxPasswordLen {
+		if !h.TruncateLong {
+			return ErrPasswordTooLong
+		}
+		password = password[:MaxPasswordLen]
+	}
+	err := bcrypt.CompareHashAndPassword(hash, password)
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return ErrInvalidPassword
+	}
+	return err
+}
+
+// NeedsRehash tells whether the hash is not a bcrypt one, or has a lower cost
+func (h *BcryptHasher) NeedsRehash(hash []byte) bool {
+	cost, err := bcrypt.Cost(hash)
+	return err != nil || cost < h.cost()
+}
+
+// maxArgon2Memory is the maximum memory in KiB of the stored argon2id and
+// scrypt hashes, so that a crafted hash can't exhaust the memory
+const maxArgon2Memory = 1 << 22
+
+// maxPBKDF2Iterations and maxScryptP bound the work of checking a password
+// against a stored hash, so that a crafted hash can't hold a core for minutes
+const (
+	maxPBKDF2Iterations = 10000000
+	maxScryptP          = 16
+)
+
+// Argon2idHasher hashes passwords with argon2id. Zero fields take the
+// defaults of NewArgon2idHasher
+type Argon2idHasher struct {
+	Time    uint32 // Number of passes
+	Memory  uint32 // Memory in KiB
+	Threads uint8
+	KeyLen  uint32
+	SaltLen int
+}
+
+// NewArgon2idHasher returns an argon2id hasher with the parameters recommended by RFC 9106
+func NewArgon2idHasher() *Argon2idHasher {
+	return &Argon2idHasher{Time: 3, Memory: 64 * 1024, Threads: 4, KeyLen: 32, SaltLen: 16}
+}
+
+// params returns the parameters of the hasher, with the defaults of
+// NewArgon2idHasher for the zero ones
+func (h *Argon2idHasher) params() Argon2idHasher {
+	p, def := *h, NewArgon2idHasher()
+	if p.Time == 0 {
+		p.Time = def.Time
+	}
+	if p.Memory == 0 {
+		p.Memory = def.Memory
+	}
+	if p.Threads == 0 {
+		p.Threads = def.Threads
+	}
+	if p.KeyLen == 0 {
+		p.KeyLen = def.KeyLen
+	}
+	if p.Memory < 8*uint32(p.Threads) {
+		// as argon2 does, which would store the wrong memory otherwise
+		p.Memory = 8 * uint32(p.Threads)
+	}
+	return p
+}
+
+// Hash returns the argon2id hash of the password
+func (h *Argon2idHasher) Hash(password []byte) ([]byte, error) {
+	hp := h.params()
+	h = &hp
+	salt, err := newSalt(h.SaltLen)
+	if err != nil {
+		return nil, err
+	}
+	key := argon2.IDKey(password, salt, h.Time, h.Memory, h.Threads, h.KeyLen)
+	params := fmt.Sprintf("m=%d,t=%d,p=%d", h.Memory, h.Time, h.Threads)
+	return encodePHC("argon2id", fmt.Sprintf("v=%d", argon2.Version), params, salt, key), nil
+}
+
+// Verify checks the password against an argon2id hash
+func (h *Argon2idHasher) Verify(hash, password []byte) error {
+	p, err := h.parse(hash)
+	if err != nil {
+		return err
+	}
+	key := argon2.IDKey(password, p.salt, p.Time, p.Memory, p.Threads, uint32(len(p.key)))
+	return compareKeys(key, p.key)
+}
+
+// NeedsRehash tells whether the hash is not an argon2id one, or has weaker parameters
+func (h *Argon2idHasher) NeedsRehash(hash []byte) bool {
+	hp := h.params()
+	h = &hp
+	p, err := h.parse(hash)
+	return err != nil || p.Time < h.Time || p.Memory < h.Memory || p.Threads < h.Threads || len(p.key) < int(h.KeyLen)
+}
+
+type argon2idParams struct {
+	Argon2idHasher
+	salt, key []byte
+}
+
+func (h *Argon2idHasher) parse(hash []byte) (*argon2idParams, error) {
+	phc, err := parsePHC(hash, "argon2id")
+	if err != nil {
+		return nil, err
+	}
+	if phc.version != strconv.Itoa(argon2.Version) {
+		return nil, fmt.Errorf("%w: unsupported argon2 version %s", ErrUnknownHash, phc.version)
+	}
+	p := &argon2idParams{salt: phc.salt, key: phc.key}
+	var threads uint32
+	err = phc.uint32Params(map[string]*uint32{"m": &p.Memory, "t": &p.Time, "p": &threads})
+	if err != nil || threads > 255 {
+		return nil, fmt.Errorf("%w: invalid argon2id parameters", ErrUnknownHash)
+	}
+	if p.Time < 1 || threads < 1 || p.Memory < 8*threads || p.Memory > maxArgon2Memory {
+		return nil, fmt.Errorf("%w: argon2id parameters out of range", ErrUnknownHash)
+	}
+	p.Threads = uint8(threads)
+	return p, nil
+}
+
+// ScryptHasher hashes passwords with scrypt. Zero fields take the defaults
+// of NewScryptHasher
+type ScryptHasher struct {
+	LogN    uint32 // Base 2 logarithm of the CPU and memory cost N
+	R, P    uint32 // Block size and parallelization
+	KeyLen  int
+	SaltLen int
+}
+
+// NewScryptHasher returns an scrypt hasher with the parameters recommended by OWASP
+func NewScryptHasher() *ScryptHasher {
+	return &ScryptHasher{LogN: 17, R: 8, P: 1, KeyLen: 32, SaltLen: 16}
+}
+
+// params returns the parameters of the hasher, with the defaults of
+// NewScryptHasher for the zero ones
+func (h *ScryptHasher) params() ScryptHasher {
+	p, def := *h, NewScryptHasher()
+	if p.LogN == 0 {
+		p.LogN = def.LogN
+	}
+	if p.R == 0 {
+		p.R = def.R
+	}
+	if p.P == 0 {
+		p.P = def.P
+	}
+	if p.KeyLen == 0 {
+		p.KeyLen = def.KeyLen
+	}
+	return p
+}
+
+// Hash returns the scrypt hash of the password
+func (h *ScryptHasher) Hash(password []byte) ([]byte, error) {
+	hp := h.params()
+	h = &hp
+	salt, err := newSalt(h.SaltLen)
+	if err != nil {
+		return nil, err
+	}
+	key, err := scrypt.Key(password, salt, 1<<h.LogN, int(h.R), int(h.P), h.KeyLen)
+	if err != nil {
+		return nil, err
+	}
+	params := fmt.Sprintf("ln=%d,r=%d,p=%d", h.LogN, h.R, h.P)
+	return encodePHC("scrypt", "", params, salt, key), nil
+}
+
+// Verify checks the password against an scrypt hash
+func (h *ScryptHasher) Verify(hash, password []byte) error {
+	p, err := h.parse(hash)
+	if err != nil {
+		return err
+	}
+	key, err := scrypt.Key(password, p.salt, 1<<p.LogN, int(p.R), int(p.P), len(p.key))
+	if err != nil {
+		return err
+	}
+	return compareKeys(key, p.key)
+}
+
+// NeedsRehash tells whether the hash is not an scrypt one, or has weaker parameters
+func (h *ScryptHasher) NeedsRehash(hash []byte) bool {
+	hp := h.params()
+	h = &hp
+	p, err := h.parse(hash)
+	return err != nil || p.LogN < h.LogN || p.R < h.R || p.P < h.P || len(p.key) < h.KeyLen
+}
+
+type scryptParams struct {
+	ScryptHasher
+	salt, key []byte
+}
+
+func (h *ScryptHasher) parse(hash []byte) (*scryptParams, error) {
+	phc, err := parsePHC(hash, "scrypt")
+	if err != nil {
+		return nil, err
+	}
+	p := &scryptParams{salt: phc.salt, key: phc.key}
+	err = phc.uint32Params(map[string]*uint32{"ln": &p.LogN, "r": &p.R, "p": &p.P})
+	if err != nil || p.LogN == 0 || p.LogN > 31 {
+		return nil, fmt.Errorf("%w: invalid scrypt parameters", ErrUnknownHash)
+	}
+	if p.R < 1 || p.P < 1 || p.P > maxScryptP || 128*uint64(p.R)<<p.LogN > maxArgon2Memory*1024 {
+		return nil, fmt.Errorf("%w: scrypt parameters out of range", ErrUnknownHash)
+	}
+	return p, nil
+}
+
+// PBKDF2Hasher hashes passwords with PBKDF2. Zero fields take the defaults
+// of NewPBKDF2Hasher
+type PBKDF2Hasher struct {
+	Iterations uint32
+	Hash512    bool // Use HMAC-SHA512 instead of HMAC-SHA256
+	KeyLen     int
+	SaltLen    int
+}
+
+// NewPBKDF2Hasher returns a PBKDF2 hasher using HMAC-SHA256 with the iterations recommended by OWASP
+func NewPBKDF2Hasher() *PBKDF2Hasher {
+	return &PBKDF2Hasher{Iterations: 600000, KeyLen: 32, SaltLen: 16}
+}
+
+func (h *PBKDF2Hasher) id() string {
+	if h.Hash512 {
+		return "pbkdf2-sha512"
+	}
+	return "pbkdf2-sha256"
+}
+
+func pbkdf2Hash(id string) func() hash.Hash {
+	if id == "pbkdf2-sha512" {
+		return sha512.New
+	}
+	return sha256.New
+}
+
+// params returns the parameters of the hasher, with the defaults of
+// NewPBKDF2Hasher for the zero ones
+func (h *PBKDF2Hasher) params() PBKDF2Hasher {
+	p, def := *h, NewPBKDF2Hasher()
+	if p.Iterations == 0 {
+		p.Iterations = def.Iterations
+	}
+	if p.KeyLen == 0 {
+		p.KeyLen = def.KeyLen
+	}
+	return p
+}
+
+// Hash returns the PBKDF2 hash of the password
+func (h *PBKDF2Hasher) Hash(password []byte) ([]byte, error) {
+	hp := h.params()
+	h = &hp
+	salt, err := newSalt(h.SaltLen)
+	if err != nil {
+		return nil, err
+	}
+	key := pbkdf2.Key(password, salt, int(h.Iterations), h.KeyLen, pbkdf2Hash(h.id()))
+	return encodePHC(h.id(), "", fmt.Sprintf("i=%d", h.Iterations), salt, key), nil
+}
+
+// Verify checks the password against a PBKDF2 hash, using either HMAC-SHA256 or HMAC-SHA512
+func (h *PBKDF2Hasher) Verify(hash, password []byte) error {
+	id := hashID(hash)
+	p, err := parsePBKDF2(hash, id)
+	if err != nil {
+		return err
+	}
+	key := pbkdf2.Key(password, p.salt, int(p.Iterations), len(p.key), pbkdf2Hash(id))
+	return compareKeys(key, p.key)
+}
+
+// NeedsRehash tells whether the hash is not a PBKDF2 one with the same HMAC, or has weaker parameters
+func (h *PBKDF2Hasher) NeedsRehash(hash []byte) bool {
+	hp := h.params()
+	h = &hp
+	p, err := parsePBKDF2(hash, h.id())
+	return err != nil || p.Iterations < h.Iterations || len(p.key) < h.KeyLen
+}
+
+type pbkdf2Params struct {
+	PBKDF2Hasher
+	salt, key []byte
+}
+
+func parsePBKDF2(hash []byte, id string) (*pbkdf2Params, error) {
+	if id != "pbkdf2-sha256" && id != "pbkdf2-sha512" {
+		return nil, ErrUnknownHash
+	}
+	phc, err := parsePHC(hash, id)
+	if err != nil {
+		return nil, err
+	}
+	p := &pbkdf2Params{salt: phc.salt, key: phc.key}
+	if err := phc.uint32Params(map[string]*uint32{"i": &p.Iterations}); err != nil || p.Iterations == 0 {
+		return nil, fmt.Errorf("%w: invalid pbkdf2 parameters", ErrUnknownHash)
+	}
+	if p.Iterations > maxPBKDF2Iterations {
+		return nil, fmt.Errorf("%w: pbkdf2 parameters out of range", ErrUnknownHash)
+	}
+	return p, nil
+}
+
+// phcString holds the fields of a hash in PHC string format:
+// $id[$v=version][$param=value,...][$salt[$hash]]
+type phcString struct {
+	version string
+	params  map[string]string
+	salt    []byte
+	key     []byte
+}
+
+var phcEncoding = base64.RawStdEncoding
+
+func encodePHC(id, version, params string, salt, key []byte) []byte {
+	fields := []string{"", id}
+	if version != "" {
+		fields = append(fields, version)
+	}
+	fields = append(fields, params, phcEncoding.EncodeToString(salt), phcEncoding.EncodeToString(key))
+	return []byte(strings.Join(fields, "$"))
+}
+
+// parsePHC parses a hash of the given algorithm, which must have salt and hash
+func parsePHC(hash []byte, id string) (*phcString, error) {
+	fields := strings.Split(string(hash), "$")
+	if len(fields) < 5 || fields[0] != "" || fields[1] != id {
+		return nil, ErrUnknownHash
+	}
+	phc := &phcString{params: make(map[string]string)}
+	fields = fields[2:]
+	if strings.HasPrefix(fields[0], "v=") {
+		phc.version = strings.TrimPrefix(fields[0], "v=")
+		fields = fields[1:]
+	}
+	if len(fields) != 3 {
+		return nil, ErrUnknownHash
+	}
+	for _, param := range strings.Split(fields[0], ",") {
+		key, val, _ := strings.Cut(param, "=")
+		phc.params[key] = val
+	}
+	var err error
+	if phc.salt, err = phcEncoding.DecodeString(fields[1]); err != nil {
+		return nil, fmt.Errorf("%w: invalid salt", ErrUnknownHash)
+	}
+	if phc.key, err = phcEncoding.DecodeString(fields[2]); err != nil || len(phc.key) == 0 {
+		return nil, fmt.Errorf("%w: invalid hash", ErrUnknownHash)
+	}
+	return phc, nil
+}
+
+// uint32Params parses the given parameters, which must all be present
+func (phc *phcString) uint32Params(params map[string]*uint32) error {
+	for name, dest := range params {
+		n, err := strconv.ParseUint(phc.params[name], 10, 32)
+		if err != nil {
+			return err
+		}
+		*dest = uint32(n)
+	}
+	return nil
+}
+
+func newSalt(n int) ([]byte, error) {
+	if n <= 0 {
+		n = 16
+	}
+	salt := make([]byte, n)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+func compareKeys(a, b []byte) error {
+	if subtle.ConstantTimeCompare(a, b) != 1 {
+		return ErrInvalidPassword
+	}
+	return nil
+}
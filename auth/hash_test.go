@@ -0,0 +1,180 @@
+package auth_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/syb-devs/gotools/auth"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// hashers with cheap parameters, to keep the tests fast
+var testHashers = []struct {
+	prefix string
+	hasher auth.Hasher
+	weaker auth.Hasher
+}{
+	{"$2a$04$", &auth.BcryptHasher{Cost: bcrypt.MinCost}, &auth.BcryptHasher{Cost: bcrypt.MinCost + 1}},
+	{"$argon2id$v=19$m=64,t=1,p=1$", &auth.Argon2idHasher{Time: 1, Memory: 64, Threads: 1, KeyLen: 32}, &auth.Argon2idHasher{Time: 2, Memory: 64, Threads: 1, KeyLen: 32}},
+	{"$scrypt$ln=4,r=8,p=1$", &auth.ScryptHasher{LogN: 4, R: 8, P: 1, KeyLen: 32}, &auth.ScryptHasher{LogN: 5, R: 8, P: 1, KeyLen: 32}},
+	{"$pbkdf2-sha256$i=100$", &auth.PBKDF2Hasher{Iterations: 100, KeyLen: 32}, &auth.PBKDF2Hasher{Iterations: 200, KeyLen: 32}},
+	{"$pbkdf2-sha512$i=100$", &auth.PBKDF2Hasher{Iterations: 100, Hash512: true, KeyLen: 64}, &auth.PBKDF2Hasher{Iterations: 100, KeyLen: 32}},
+}
+
+func TestHashers(t *testing.T) {
+	plain := []byte("7h1$ 1$ 50m37h!n6")
+	for i, tc := range testHashers {
+		a := auth.New()
+		if err := a.GeneratePasswordHasher(plain, tc.hasher); err != nil {
+			t.Fatalf("#%d: unexpected error: %v", i, err)
+		}
+		if !strings.HasPrefix(string(a.Password), tc.prefix) {
+			t.Errorf("#%d: expecting prefix %s, got %s", i, tc.prefix, a.Password)
+		}
+		if err := a.CheckPassword(plain); err != nil {
+			t.Errorf("#%d: checking password: %v", i, err)
+		}
+		if err := a.CheckPassword([]byte("invalid password")); err != auth.ErrInvalidPassword {
+			t.Errorf("#%d: expecting invalid password error, got: %v", i, err)
+		}
+		if tc.hasher.NeedsRehash(a.Password) {
+			t.Errorf("#%d: the hash should not need rehashing with the same hasher", i)
+		}
+		if !tc.weaker.NeedsRehash(a.Password) {
+			t.Errorf("#%d: the hash should need rehashing with stronger parameters", i)
+		}
+		for j, other := range testHashers {
+			if j != i && tc.prefix[:4] != other.prefix[:4] && !other.hasher.NeedsRehash(a.Password) {
+				t.Errorf("#%d: the hash should need rehashing with hasher #%d", i, j)
+			}
+		}
+	}
+}
+
+func TestHashUpgrade(t *testing.T) {
+	a := auth.New()
+	plain := []byte("7h1$ 1$ 50m37h!n6")
+	if err := a.GeneratePasswordCost(plain, bcrypt.MinCost); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	a.Hasher = &auth.Argon2idHasher{Time: 1, Memory: 64, Threads: 1, KeyLen: 32}
+	if !a.NeedsRehash() {
+		t.Error("a bcrypt hash should need rehashing with argon2id")
+	}
+	rehashed, err := a.CheckPasswordRehash(plain)
+	if err != nil || !rehashed {
+		t.Fatalf("expecting the password to be rehashed, got %v, %v", rehashed, err)
+	}
+	if !strings.HasPrefix(string(a.Password), "$argon2id$") {
+		t.Errorf("expecting an argon2id hash, got %s", a.Password)
+	}
+	if err := a.CheckPassword(plain); err != nil {
+		t.Errorf("checking password: %v", err)
+	}
+}
+
+func TestHashUnknown(t *testing.T) {
+	tests := []string{
+		"",
+		"plain",
+		"$md5$salt$hash",
+		"$argon2id$v=16$m=64,t=1,p=1$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=64,t=1$c2FsdA$aGFzaA",
+		"$scrypt$ln=4,r=8,p=1$c2FsdA",
+		"$pbkdf2-sha256$i=100$c2FsdA$not base64",
+		"$argon2id$v=19$m=64,t=0,p=1$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=64,t=1,p=0$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=8,t=1,p=4$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=4294967295,t=1,p=1$c2FsdA$aGFzaA",
+		"$scrypt$ln=31,r=8,p=1$c2FsdA$aGFzaA",
+		"$scrypt$ln=4,r=0,p=1$c2FsdA$aGFzaA",
+		"$scrypt$ln=4,r=8,p=4294967295$c2FsdA$aGFzaA",
+		"$pbkdf2-sha256$i=4294967295$c2FsdA$aGFzaA",
+		"$pbkdf2-sha512$i=20000000$c2FsdA$aGFzaA",
+	}
+	for i, hash := range tests {
+		a := &auth.Auth{Password: []byte(hash)}
+		if err := a.CheckPassword([]byte("password")); !errors.Is(err, auth.ErrUnknownHash) {
+			t.Errorf("#%d: expecting unknown hash error, got %v", i, err)
+		}
+	}
+}
+
+func TestZeroHashers(t *testing.T) {
+	plain := []byte("7h1$ 1$ 50m37h!n6")
+	tests := []struct {
+		hasher, defaults auth.Hasher
+	}{
+		{&auth.Argon2idHasher{}, auth.NewArgon2idHasher()},
+		{&auth.ScryptHasher{}, auth.NewScryptHasher()},
+		{&auth.PBKDF2Hasher{}, auth.NewPBKDF2Hasher()},
+	}
+	for i, tc := range tests {
+		a := auth.New()
+		if err := a.GeneratePasswordHasher(plain, tc.hasher); err != nil {
+			t.Fatalf("#%d: unexpected error: %v", i, err)
+		}
+		if err := a.CheckPassword(plain); err != nil {
+			t.Errorf("#%d: checking password: %v", i, err)
+		}
+		if tc.defaults.NeedsRehash(a.Password) || tc.hasher.NeedsRehash(a.Password) {
+			t.Errorf("#%d: the hash should have the default parameters, got %s", i, a.Password)
+		}
+	}
+}
+
+// plainHasher stores the passwords as is, to test custom hashers
+type plainHasher struct{}
+
+func (plainHasher) Hash(password []byte) ([]byte, error) {
+	return append([]byte("$plain$"), password...), nil
+}
+
+func (plainHasher) Verify(hash, password []byte) error {
+	if !strings.HasPrefix(string(hash), "$plain$") {
+		return auth.ErrUnknownHash
+	}
+	if string(hash[len("$plain$"):]) != string(password) {
+		return auth.ErrInvalidPassword
+	}
+	return nil
+}
+
+func (plainHasher) NeedsRehash(hash []byte) bool {
+	return !strings.HasPrefix(string(hash), "$plain$")
+}
+
+func TestCustomHasher(t *testing.T) {
+	plain := []byte("7h1$ 1$ 50m37h!n6")
+	a := &auth.Auth{Hasher: plainHasher{}}
+	if err := a.GeneratePassword(plain); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := a.CheckPassword(plain); err != nil {
+		t.Errorf("checking password with the hasher of the account: %v", err)
+	}
+
+	b := &auth.Auth{Password: a.Password}
+	if err := b.CheckPassword(plain); err != auth.ErrUnknownHash {
+		t.Errorf("expecting unknown hash error, got %v", err)
+	}
+	auth.RegisterHasher("plain", plainHasher{})
+	if err := b.CheckPassword(plain); err != nil {
+		t.Errorf("checking password with the registered hasher: %v", err)
+	}
+	if err := b.CheckPassword([]byte("invalid password")); err != auth.ErrInvalidPassword {
+		t.Errorf("expecting invalid password error, got: %v", err)
+	}
+
+	if err := a.GeneratePasswordCost(plain, bcrypt.MinCost); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := a.CheckPassword(plain); err != nil {
+		t.Errorf("checking a bcrypt password with a custom hasher: %v", err)
+	}
+	if !a.NeedsRehash() {
+		t.Error("a bcrypt hash should need rehashing with the custom hasher")
+	}
+}
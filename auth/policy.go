@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrForbidden is returned by Decision.Err when the access was denied
+var ErrForbidden = errors.New("forbidden")
+
+// Resource is the object of an access check, like a document, with
+// attributes such as its owner, tenant or state
+type Resource struct {
+	Type  string
+	ID    string
+	Attrs map[string]string
+}
+
+func (res *Resource) String() string {
+	switch {
+	case res == nil:
+		return "no resource"
+	case res.Type == "" && res.ID == "":
+		return "resource"
+	case res.ID == "":
+		return res.Type
+	}
+	return res.Type + " " + res.ID
+}
+
+// Predicate tells whether an account may use a capability on a resource
+type Predicate func(a *Auth, res *Resource) bool
+
+// Owner returns a predicate that holds when the attribute of the resource,
+// like "owner", is the username of the account
+func Owner(attr string) Predicate {
+	return func(a *Auth, res *Resource) bool {
+		return res.Attrs[attr] != "" && res.Attrs[attr] == a.Username
+	}
+}
+
+// SameAttr returns a predicate that holds when the account and the resource
+// have the same non empty value for the attribute, like "tenant"
+func SameAttr(attr string) Predicate {
+	return func(a *Auth, res *Resource) bool {
+		return res.Attrs[attr] != "" && res.Attrs[attr] == a.Attrs[attr]
+	}
+}
+
+// AttrIn returns a predicate that holds when the attribute of the resource,
+// like "state", has one of the given values
+func AttrIn(attr string, values ...string) Predicate {
+	return func(a *Auth, res *Resource) bool {
+		for _, v := range values {
+			if res.Attrs[attr] == v {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+type policy struct {
+	name       string
+	capability string
+	pred       Predicate
+}
+
+// RegisterPolicy registers a named predicate for the capabilities matching
+// the pattern, replacing the policy with the same name. Policies restrict the
+// capabilities granted by the roles when they are checked on a resource.
+// Without a resource, as with Auth.Can, the capabilities restricted by a
+// policy are denied. It panics if the predicate is nil
+func (r *Registry) RegisterPolicy(name, capability string, pred Predicate) {
+	if pred == nil {
+		panic("auth: nil predicate for policy " + name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p := policy{name: name, capability: capability, pred: pred}
+	for i := range r.policies {
+		if r.policies[i].name == name {
+			r.policies[i] = p
+			return
+		}
+	}
+	r.policies = append(r.policies, p)
+}
+
+// RemovePolicy removes the named policy
+func (r *Registry) RemovePolicy(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.policies {
+		if r.policies[i].name == name {
+			r.policies = append(r.policies[:i], r.policies[i+1:]...)
+			return
+		}
+	}
+}
+
+// policiesFor returns the policies applying to the capability, in registration order
+func (r *Registry) policiesFor(capability string) []policy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var policies []policy
+	for _, p := range r.policies {
+		if matchCapability(p.capability, capability) {
+			policies = append(policies, p)
+		}
+	}
+	return policies
+}
+
+// RegisterPolicy registers a named predicate for the capabilities matching
+// the pattern in DefaultRegistry
+func RegisterPolicy(name, capability string, pred Predicate) {
+	DefaultRegistry.RegisterPolicy(name, capability, pred)
+}
+
+// Decision is the result of an access check, telling why it was granted or denied
+type Decision struct {
+	Allowed    bool
+	Capability string
+	Resource   *Resource
+	Role       string   // Role granting or denying the capability, empty if none has it
+	Rule       string   // Capability of the role matching the checked one
+	Policies   []string // Policies that held
+	Policy     string   // Policy that didn't hold, denying the access
+}
+
+// Reason explains the decision
+func (d Decision) Reason() string {
+	switch {
+	case d.Role == "":
+		return fmt.Sprintf("denied %s: no role grants it", d.Capability)
+	case d.Policy != "" && d.Resource == nil:
+		return fmt.Sprintf("denied %s by policy %s, which needs a resource, though granted by role %s (%s)",
+			d.Capability, d.Policy, d.Role, d.Rule)
+	case d.Policy != "":
+		return fmt.Sprintf("denied %s on %s by policy %s, though granted by role %s (%s)",
+			d.Capability, d.Resource, d.Policy, d.Role, d.Rule)
+	case !d.Allowed:
+		return fmt.Sprintf("denied %s by role %s (%s)", d.Capability, d.Role, d.Rule)
+	}
+	reason := "granted " + d.Capability
+	if d.Resource != nil {
+		reason += " on " + d.Resource.String()
+	}
+	reason += fmt.Sprintf(" by role %s (%s)", d.Role, d.Rule)
+	if len(d.Policies) > 0 {
+		reason += ", with policies " + strings.Join(d.Policies, ", ")
+	}
+	return reason
+}
+
+func (d Decision) String() string {
+	return d.Reason()
+}
+
+// Err returns nil if the access was allowed, or ErrForbidden with the reason
+func (d Decision) Err() error {
+	if d.Allowed {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrForbidden, d.Reason())
+}
+
+// Authorize checks the capability on the resource: one of the roles must
+// grant it without any denying it, and every policy registered for it must
+// hold. The predicates are called in registration order, until one fails.
+// With a nil resource, the capability is denied if any policy applies to it
+func (a *Auth) Authorize(capability string, res *Resource) Decision {
+	reg := a.registry()
+	d := reg.match(a.Roles, capability)
+	d.Resource = res
+	if !d.Allowed {
+		return d
+	}
+	policies := reg.policiesFor(capability)
+	if res == nil && len(policies) > 0 {
+		d.Allowed, d.Policy = false, policies[0].name
+		return d
+	}
+	for _, p := range policies {
+		if !p.pred(a, res) {
+			d.Allowed, d.Policy = false, p.name
+			return d
+		}
+		d.Policies = append(d.Policies, p.name)
+	}
+	return d
+}
+
+// CanOn checks if the user has the capability on the resource, as Authorize does
+func (a *Auth) CanOn(capability string, res *Resource) bool {
+	return a.Authorize(capability, res).Allowed
+}
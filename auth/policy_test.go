@@ -0,0 +1,119 @@
+package auth_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/syb-devs/gotools/auth"
+)
+
+func TestAuthorize(t *testing.T) {
+	r := auth.NewRegistry()
+	r.Register("author", "docs:edit", "docs:view")
+	r.Register("editor", "docs:*")
+	r.Register("suspended", "!docs:*")
+	r.RegisterPolicy("tenant", "docs:*", auth.SameAttr("tenant"))
+	r.RegisterPolicy("owner", "docs:edit", func(a *auth.Auth, res *auth.Resource) bool {
+		return a.HasRole("editor") || auth.Owner("owner")(a, res)
+	})
+	r.RegisterPolicy("draft", "docs:publish", auth.AttrIn("state", "draft", "review"))
+
+	doc := &auth.Resource{Type: "document", ID: "42", Attrs: map[string]string{"owner": "ann", "tenant": "acme", "state": "draft"}}
+	published := &auth.Resource{Type: "document", ID: "7", Attrs: map[string]string{"owner": "bob", "tenant": "acme", "state": "published"}}
+	other := &auth.Resource{Type: "document", ID: "9", Attrs: map[string]string{"owner": "ann", "tenant": "globex"}}
+	account := func(name string, roles ...string) *auth.Auth {
+		return &auth.Auth{Username: name, Roles: roles, Attrs: map[string]string{"tenant": "acme"}, Registry: r}
+	}
+
+	tests := []struct {
+		a          *auth.Auth
+		capability string
+		res        *auth.Resource
+		want       auth.Decision
+		reason     string
+	}{
+		{
+			account("ann", "author"), "docs:edit", doc,
+			auth.Decision{Allowed: true, Capability: "docs:edit", Resource: doc, Role: "author", Rule: "docs:edit", Policies: []string{"tenant", "owner"}},
+			"granted docs:edit on document 42 by role author (docs:edit), with policies tenant, owner",
+		},
+		{
+			account("bob", "author"), "docs:edit", doc,
+			auth.Decision{Capability: "docs:edit", Resource: doc, Role: "author", Rule: "docs:edit", Policies: []string{"tenant"}, Policy: "owner"},
+			"denied docs:edit on document 42 by policy owner, though granted by role author (docs:edit)",
+		},
+		{
+			account("bob", "editor"), "docs:edit", doc,
+			auth.Decision{Allowed: true, Capability: "docs:edit", Resource: doc, Role: "editor", Rule: "docs:*", Policies: []string{"tenant", "owner"}},
+			"granted docs:edit on document 42 by role editor (docs:*), with policies tenant, owner",
+		},
+		{
+			account("ann", "author"), "docs:edit", other,
+			auth.Decision{Capability: "docs:edit", Resource: other, Role: "author", Rule: "docs:edit", Policy: "tenant"},
+			"denied docs:edit on document 9 by policy tenant, though granted by role author (docs:edit)",
+		},
+		{
+			account("bob", "editor"), "docs:publish", published,
+			auth.Decision{Capability: "docs:publish", Resource: published, Role: "editor", Rule: "docs:*", Policies: []string{"tenant"}, Policy: "draft"},
+			"denied docs:publish on document 7 by policy draft, though granted by role editor (docs:*)",
+		},
+		{
+			account("ann", "author"), "docs:publish", doc,
+			auth.Decision{Capability: "docs:publish", Resource: doc},
+			"denied docs:publish: no role grants it",
+		},
+		{
+			account("ann", "author", "suspended"), "docs:view", doc,
+			auth.Decision{Capability: "docs:view", Resource: doc, Role: "suspended", Rule: "!docs:*"},
+			"denied docs:view by role suspended (!docs:*)",
+		},
+	}
+	for i, tc := range tests {
+		have := tc.a.Authorize(tc.capability, tc.res)
+		if !reflect.DeepEqual(have, tc.want) {
+			t.Errorf("#%d: mismatch\nhave %#+v\nwant %#+v", i, have, tc.want)
+		}
+		if reason := have.Reason(); reason != tc.reason {
+			t.Errorf("#%d: mismatch\nhave %s\nwant %s", i, reason, tc.reason)
+		}
+		if tc.a.CanOn(tc.capability, tc.res) != tc.want.Allowed {
+			t.Errorf("#%d: CanOn should agree with Authorize", i)
+		}
+		if err := have.Err(); (err == nil) != tc.want.Allowed || (err != nil && !errors.Is(err, auth.ErrForbidden)) {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+		}
+	}
+}
+
+func TestRemovePolicy(t *testing.T) {
+	r := auth.NewRegistry()
+	r.Register("author", "docs:edit")
+	r.RegisterPolicy("owner", "docs:*", auth.Owner("owner"))
+
+	a := &auth.Auth{Username: "bob", Roles: []string{"author"}, Registry: r}
+	doc := &auth.Resource{Attrs: map[string]string{"owner": "ann"}}
+	if a.CanOn("docs:edit", doc) || a.CanOn("docs:edit", nil) {
+		t.Error("the owner policy should deny the access")
+	}
+	if a.Can("docs:edit") {
+		t.Error("the owner policy should deny the access without a resource")
+	}
+	want := "denied docs:edit by policy owner, which needs a resource, though granted by role author (docs:edit)"
+	if reason := a.Authorize("docs:edit", nil).Reason(); reason != want {
+		t.Errorf("mismatch\nhave %s\nwant %s", reason, want)
+	}
+	r.RemovePolicy("owner")
+	if !a.CanOn("docs:edit", doc) || !a.Can("docs:edit") {
+		t.Error("the removed policy should not deny the access")
+	}
+}
+
+func TestNilPolicy(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expecting a panic for a nil predicate")
+		}
+	}()
+	auth.NewRegistry().RegisterPolicy("owner", "docs:*", nil)
+}
@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrRoleCycle is returned when a role would inherit from itself
+var ErrRoleCycle = errors.New("role inheritance cycle")
+
+// Role is a role with its own capabilities and the roles it inherits from
+type Role struct {
+	Name         string
+	Capabilities []string
+	Parents      []string
+}
+
+// Registry holds roles and their capabilities. It is safe for concurrent
+// use, and the zero value is an empty registry ready to use
+type Registry struct {
+	mu    sync.RWMutex
+	roles map[string]*Role
+	// effective caches the capabilities of every role, with the inherited
+	// ones, sorted
+	effective map[string][]string
+	policies  []policy
+}
+
+// NewRegistry returns an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register registers a role and its capabilities, replacing the capabilities
+// of the role if it was already registered
+func (r *Registry) Register(role string, capabilities ...string) {
+	caps := append([]string{}, capabilities...)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.role(role).Capabilities = caps
+	r.update()
+}
+
+// Inherit sets the roles a role inherits the capabilities from, replacing
+// its previous parents. Parents don't need to be registered yet. It fails
+// with ErrRoleCycle if the role would end up inheriting from itself
+func (r *Registry) Inherit(role string, parents ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, parent := range parents {
+		if path := r.path(parent, role); path != nil {
+			return fmt.Errorf("%w: %s -> %s", ErrRoleCycle, role, strings.Join(path, " -> "))
+		}
+	}
+	r.role(role).Parents = append([]string{}, parents...)
+	r.update()
+	return nil
+}
+
+// Remove removes a role, and drops it from the parents of the roles
+// inheriting from it. Accounts with the role, or with roles inheriting from
+// it, lose its capabilities, even if it is registered again
+func (r *Registry) Remove(role string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.roles, role)
+	for _, rl := range r.roles {
+		parents := rl.Parents[:0]
+		for _, parent := range rl.Parents {
+			if parent != role {
+				parents = append(parents, parent)
+			}
+		}
+		rl.Parents = parents
+	}
+	r.update()
+}
+
+// Capabilities returns the own capabilities of a role, and whether it is registered
+func (r *Registry) Capabilities(role string) ([]string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rl, ok := r.roles[role]
+	if !ok {
+		return []string{}, false
+	}
+	return append([]string{}, rl.Capabilities...), true
+}
+
+// EffectiveCapabilities returns the capabilities of a role with the
+// inherited ones, sorted
+func (r *Registry) EffectiveCapabilities(role string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string{}, r.effective[role]...)
+}
+
+// Roles returns the registered roles, sorted by name
+func (r *Registry) Roles() []Role {
+	r.mu.RLock()
+	roles := make([]Role, 0, len(r.roles))
+	for _, rl := range r.roles {
+		roles = append(roles, Role{
+			Name:         rl.Name,
+			Capabilities: copyStrings(rl.Capabilities),
+			Parents:      copyStrings(rl.Parents),
+		})
+	}
+	r.mu.RUnlock()
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles
+}
+
+// match checks the capability against the capabilities of the roles,
+// returning the decision with the role granting or denying it
+func (r *Registry) match(roles []string, capability string) Decision {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	d := Decision{Capability: capability}
+	for _, role := range roles {
+		for _, c := range r.effective[role] {
+			if strings.HasPrefix(c, DenyPrefix) {
+				if matchCapability(c[len(DenyPrefix):], capability) {
+					return Decision{Capability: capability, Role: role, Rule: c}
+				}
+			} else if !d.Allowed && matchCapability(c, capability) {
+				d.Allowed, d.Role, d.Rule = true, role, c
+			}
+		}
+	}
+	return d
+}
+
+// role returns a registered role, registering it if needed
+func (r *Registry) role(name string) *Role {
+	if r.roles == nil {
+		r.roles = make(map[string]*Role)
+	}
+	rl, ok := r.roles[name]
+	if !ok {
+		rl = &Role{Name: name}
+		r.roles[name] = rl
+	}
+	return rl
+}
+
+// path returns the roles from one role up to an ancestor, or nil if it
+// doesn't inherit from it
+func (r *Registry) path(from, to string) []string {
+	if from == to {
+		return []string{to}
+	}
+	rl, ok := r.roles[from]
+	if !ok {
+		return nil
+	}
+	for _, parent := range rl.Parents {
+		if path := r.path(parent, to); path != nil {
+			return append([]string{from}, path...)
+		}
+	}
+	return nil
+}
+
+// update computes the effective capabilities of every role again
+func (r *Registry) update() {
+	sets := make(map[string]map[string]bool, len(r.roles))
+	r.effective = make(map[string][]string, len(r.roles))
+	for name := range r.roles {
+		caps := make([]string, 0, len(r.resolve(name, sets)))
+		for c := range sets[name] {
+			caps = append(caps, c)
+		}
+		sort.Strings(caps)
+		r.effective[name] = caps
+	}
+}
+
+func (r *Registry) resolve(name string, sets map[string]map[string]bool) map[string]bool {
+	if caps, ok := sets[name]; ok {
+		return caps
+	}
+	caps := make(map[string]bool)
+	sets[name] = caps
+	rl, ok := r.roles[name]
+	if !ok {
+		return caps
+	}
+	for _, c := range rl.Capabilities {
+		caps[c] = true
+	}
+	for _, parent := range rl.Parents {
+		for c := range r.resolve(parent, sets) {
+			caps[c] = true
+		}
+	}
+	return caps
+}
+
+func copyStrings(s []string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	return append([]string{}, s...)
+}
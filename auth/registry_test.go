@@ -0,0 +1,142 @@
+package auth_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/syb-devs/gotools/auth"
+)
+
+func TestRegistry(t *testing.T) {
+	r := auth.NewRegistry()
+	r.Register("editor", "edit", "publish")
+	r.Register("viewer", "view")
+
+	a := &auth.Auth{Roles: []string{"editor"}, Registry: r}
+	if !a.Can("publish") || a.Can("view") {
+		t.Error("the editor should only have the editor capabilities")
+	}
+	if auth.New().Registry != nil {
+		t.Error("new accounts should use the default registry")
+	}
+
+	r.Register("editor", "edit")
+	if a.Can("publish") {
+		t.Error("the replaced role should not keep its old capabilities")
+	}
+	r.Remove("viewer")
+	if _, ok := r.Capabilities("viewer"); ok {
+		t.Error("the removed role should not be registered")
+	}
+
+	want := []auth.Role{{Name: "editor", Capabilities: []string{"edit"}}}
+	if have := r.Roles(); !reflect.DeepEqual(have, want) {
+		t.Errorf("mismatch\nhave %#+v\nwant %#+v", have, want)
+	}
+}
+
+func TestRegistryZero(t *testing.T) {
+	var r auth.Registry
+	if (&auth.Auth{Roles: []string{"editor"}, Registry: &r}).Can("edit") {
+		t.Error("an empty registry should not grant any capability")
+	}
+	r.Remove("editor")
+	r.Register("editor", "edit")
+	if !(&auth.Auth{Roles: []string{"editor"}, Registry: &r}).Can("edit") {
+		t.Error("the zero registry should register roles")
+	}
+}
+
+func TestRegistryConcurrent(t *testing.T) {
+	r := auth.NewRegistry()
+	a := &auth.Auth{Roles: []string{"role0", "role1"}, Registry: r}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			role := fmt.Sprintf("role%d", i%2)
+			for j := 0; j < 100; j++ {
+				r.Register(role, "read", "write")
+				a.Can("write")
+				r.Roles()
+				r.Remove(role)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestRegistryInherit(t *testing.T) {
+	r := auth.NewRegistry()
+	r.Register("viewer", "view")
+	r.Register("editor", "edit")
+	r.Register("admin", "manage")
+	if err := r.Inherit("editor", "viewer"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Inherit("admin", "editor"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	a := &auth.Auth{Roles: []string{"admin"}, Registry: r}
+	if !a.CanAll("manage", "edit", "view") {
+		t.Error("the admin should inherit the editor and viewer capabilities")
+	}
+	want := []string{"edit", "manage", "view"}
+	if have := r.EffectiveCapabilities("admin"); !reflect.DeepEqual(have, want) {
+		t.Errorf("mismatch\nhave %#+v\nwant %#+v", have, want)
+	}
+
+	r.Register("viewer", "view", "export")
+	if !a.Can("export") {
+		t.Error("the admin should inherit the capabilities added to the viewer")
+	}
+	r.Remove("editor")
+	if a.Can("edit") || a.Can("view") {
+		t.Error("the admin should lose the capabilities of the removed editor")
+	}
+	r.Register("editor", "delete everything")
+	if a.Can("delete everything") {
+		t.Error("the admin should not inherit from the editor registered again")
+	}
+	want2 := []auth.Role{
+		{Name: "admin", Capabilities: []string{"manage"}},
+		{Name: "editor", Capabilities: []string{"delete everything"}},
+		{Name: "viewer", Capabilities: []string{"view", "export"}},
+	}
+	if have := r.Roles(); !reflect.DeepEqual(have, want2) {
+		t.Errorf("mismatch\nhave %#+v\nwant %#+v", have, want2)
+	}
+}
+
+func TestRegistryCycle(t *testing.T) {
+	r := auth.NewRegistry()
+	if err := r.Inherit("b", "a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Inherit("c", "b"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		role, parent string
+		err          string
+	}{
+		{"a", "a", "role inheritance cycle: a -> a"},
+		{"a", "c", "role inheritance cycle: a -> c -> b -> a"},
+		{"b", "c", "role inheritance cycle: b -> c -> b"},
+	}
+	for i, tc := range tests {
+		err := r.Inherit(tc.role, tc.parent)
+		if !errors.Is(err, auth.ErrRoleCycle) || err.Error() != tc.err {
+			t.Errorf("#%d: mismatch\nhave %v\nwant %s", i, err, tc.err)
+		}
+	}
+	if err := r.Inherit("a", "d"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
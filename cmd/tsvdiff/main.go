@@ -0,0 +1,186 @@
+// Command tsvdiff compares two TSV files by key and prints the rows added,
+// removed and changed, or merges them.
+//
+// Usage:
+//
+//	tsvdiff -key id[,col...] [-sorted] [-format tsv] [-dialect tsv] old new
+//	tsvdiff -merge -key id[,col...] [-sorted] [-dialect tsv] old new
+//
+// Compressed files are decompressed. With -sorted, both files must be sorted
+// by key, and they're compared with bounded memory; otherwise the old file is
+// held in memory.
+//
+// The tsv format prints a change column, the columns of both files, and a
+// changed column with the names of the changed cells. Changed rows are printed
+// twice: first with their old values and "old" as change, then with their new
+// values and "changed". The json format prints a JSON object per line, with the
+// old and new rows and the changed cells.
+//
+// With -merge, the rows of both files are printed as TSV, those in both files
+// with the values of the new one, as tsv.Merge does.
+//
+// The exit status is 0 if the files have the same rows, 1 if they differ and
+// 2 if there was an error, as diff does. With -merge it's 0 unless there was
+// an error.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/syb-devs/gotools/tsv"
+)
+
+func main() {
+	key := flag.String("key", "", "comma separated key columns")
+	sorted := flag.Bool("sorted", false, "both files are sorted by key")
+	format := flag.String("format", "tsv", "output format: tsv or json")
+	dialect := flag.String("dialect", "tsv", "input dialect: tsv, iana, mysql, csv or pipe")
+	merge := flag.Bool("merge", false, "print the merged rows of both files")
+	flag.Parse()
+
+	if flag.NArg() != 2 || *key == "" {
+		fmt.Fprintln(os.Stderr, "usage: tsvdiff [-merge] -key id[,col...] [-sorted] [-format tsv|json] [-dialect tsv] old new")
+		os.Exit(2)
+	}
+	opts := tsv.DiffOptions{Key: strings.Split(*key, ","), Sorted: *sorted}
+	if *merge {
+		if err := runMerge(opts, *dialect, flag.Arg(0), flag.Arg(1), os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "tsvdiff: %v\n", err)
+			os.Exit(2)
+		}
+		return
+	}
+	changes, err := run(opts, *format, *dialect, flag.Arg(0), flag.Arg(1), os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tsvdiff: %v\n", err)
+		os.Exit(2)
+	}
+	if changes > 0 {
+		os.Exit(1)
+	}
+}
+
+// printer prints changes in an output format
+type printer interface {
+	print(c tsv.Change) error
+	flush() error
+}
+
+func run(opts tsv.DiffOptions, format, dialect, oldFile, newFile string, out io.Writer) (int, error) {
+	d, ok := tsv.DialectByName(dialect)
+	if !ok {
+		return 0, fmt.Errorf("unknown dialect %q", dialect)
+	}
+	var p printer
+	switch format {
+	case "tsv":
+		p = &tsvPrinter{w: tsv.NewWriter(out)}
+	case "json":
+		p = &jsonPrinter{enc: json.NewEncoder(out)}
+	default:
+		return 0, fmt.Errorf("unknown format %q", format)
+	}
+
+	old := tsv.OpenFiles(d, oldFile)
+	defer old.Close()
+	new := tsv.OpenFiles(d, newFile)
+	defer new.Close()
+
+	changes := 0
+	err := tsv.Diff(old, new, opts, func(c tsv.Change) error {
+		changes++
+		return p.print(c)
+	})
+	if ferr := p.flush(); err == nil {
+		err = ferr
+	}
+	return changes, err
+}
+
+func runMerge(opts tsv.DiffOptions, dialect, oldFile, newFile string, out io.Writer) error {
+	d, ok := tsv.DialectByName(dialect)
+	if !ok {
+		return fmt.Errorf("unknown dialect %q", dialect)
+	}
+	old := tsv.OpenFiles(d, oldFile)
+	defer old.Close()
+	new := tsv.OpenFiles(d, newFile)
+	defer new.Close()
+	return tsv.Merge(old, new, tsv.NewWriter(out), opts)
+}
+
+type tsvPrinter struct {
+	w      *tsv.Writer
+	header bool
+}
+
+func (p *tsvPrinter) print(c tsv.Change) error {
+	if !p.header {
+		header := append([]string{"change"}, c.Columns...)
+		if err := p.w.Write(append(header, "changed")); err != nil {
+			return err
+		}
+		p.header = true
+	}
+	changed := make([]string, len(c.Cells))
+	for i, cell := range c.Cells {
+		changed[i] = cell.Column
+	}
+	switch c.Kind {
+	case tsv.Removed:
+		return p.row(c.Kind.String(), c.Columns, c.Old, "")
+	case tsv.Changed:
+		if err := p.row("old", c.Columns, c.Old, strings.Join(changed, ",")); err != nil {
+			return err
+		}
+	}
+	return p.row(c.Kind.String(), c.Columns, c.New, strings.Join(changed, ","))
+}
+
+func (p *tsvPrinter) row(change string, columns []string, vals map[string]string, changed string) error {
+	row := []string{change}
+	for _, col := range columns {
+		row = append(row, vals[col])
+	}
+	return p.w.Write(append(row, changed))
+}
+
+func (p *tsvPrinter) flush() error {
+	p.w.Flush()
+	return p.w.Error()
+}
+
+type jsonPrinter struct {
+	enc *json.Encoder
+}
+
+type jsonCell struct {
+	Column string `json:"column"`
+	Old    string `json:"old"`
+	New    string `json:"new"`
+}
+
+type jsonChange struct {
+	Change string            `json:"change"`
+	Key    []string          `json:"key"`
+	Old    map[string]string `json:"old,omitempty"`
+	New    map[string]string `json:"new,omitempty"`
+	Cells  []jsonCell        `json:"cells,omitempty"`
+}
+
+func (p *jsonPrinter) print(c tsv.Change) error {
+	jc := jsonChange{Change: c.Kind.String(), Key: c.Key, Old: c.Old, New: c.New}
+	for _, cell := range c.Cells {
+		jc.Cells = append(jc.Cells, jsonCell{Column: cell.Column, Old: cell.Old, New: cell.New})
+	}
+	return p.enc.Encode(jc)
+}
+
+func (p *jsonPrinter) flush() error {
+	return nil
+}
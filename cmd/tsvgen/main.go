@@ -0,0 +1,60 @@
+// Command tsvgen samples a TSV file, infers the type of each column and
+// prints a Go struct with the tsv tags needed to decode it with tsv.Reader.
+//
+// Usage:
+//
+//	tsvgen [-name Row] [-pkg main] [-rows 1000] [-dialect tsv] [file]
+//
+// The file is read from standard input if not given.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/syb-devs/gotools/tsv"
+)
+
+func main() {
+	name := flag.String("name", "Row", "name of the generated struct")
+	pkg := flag.String("pkg", "", "package name; if set, a complete Go file is printed")
+	rows := flag.Int("rows", 1000, "number of rows sampled to infer the column types, 0 reads them all")
+	dialect := flag.String("dialect", "tsv", "input dialect: tsv, iana, mysql, csv or pipe")
+	flag.Parse()
+
+	if err := run(*name, *pkg, *rows, *dialect, flag.Arg(0), os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "tsvgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(name, pkg string, rows int, dialect, file string, out io.Writer) error {
+	d, ok := tsv.DialectByName(dialect)
+	if !ok {
+		return fmt.Errorf("unknown dialect %q", dialect)
+	}
+	in := os.Stdin
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	r := tsv.NewDialectReader(in, d)
+	r.Lenient = true
+	schema, err := tsv.InferSchema(r, rows)
+	if err != nil {
+		return err
+	}
+	src, err := schema.GoStruct(pkg, name)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(src)
+	return err
+}
@@ -0,0 +1,307 @@
+/*
+Package fixed reads and writes fixed-width text records, mapping them to
+structs with the fixed tag:
+
+	Code   string    `fixed:"start=0,len=6"`
+	Amount int       `fixed:"start=6,len=10,align=right,pad=0"`
+	Date   time.Time `fixed:"start=16,len=8,layout=20060102"`
+
+Positions are byte offsets starting at 0. Values are aligned to the left by
+default, and padded with spaces unless the pad option says otherwise. The
+padding is trimmed before decoding, and an empty value leaves the field with
+its zero value. The layout, sep and conv options, and the conversion of
+values, are shared with the tsv package.
+*/
+package fixed
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/syb-devs/gotools/structinfo"
+	"github.com/syb-devs/gotools/tsv"
+)
+
+var (
+	// ErrEmptyRecord is returned when there's no current record to decode
+	ErrEmptyRecord = errors.New("empty record")
+
+	// ErrInvalidTag is returned for fixed tags without a valid start and len
+	ErrInvalidTag = errors.New("invalid fixed tag")
+
+	// ErrOverflow is returned when a value is longer than its field
+	ErrOverflow = errors.New("value does not fit in the field")
+)
+
+// Align is the alignment of a value within its field
+type Align int
+
+const (
+	// AlignLeft pads values on the right
+	AlignLeft Align = iota
+	// AlignRight pads values on the left, as usual for numbers
+	AlignRight
+)
+
+// ParseError is returned for values that can't be decoded
+type ParseError struct {
+	Line  int    // Line, or record number, starting at 1
+	Start int    // Start of the field
+	Field string // Name of the struct field
+	Value string // Raw value, padding included
+	Err   error  // The actual error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d, position %d, field %s: invalid value %q: %v", e.Line, e.Start, e.Field, e.Value, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// field maps a range of the record to a struct field
+type field struct {
+	index int
+	name  string
+	start int
+	len   int
+	align Align
+	pad   rune
+	codec *tsv.ValueCodec
+}
+
+// layout is the mapping of a struct type
+type layout struct {
+	fields []field
+	width  int
+}
+
+// layouts caches the layouts of the struct types used with a Reader or Writer
+type layouts map[reflect.Type]*layout
+
+func (l layouts) get(t reflect.Type, convs *tsv.Converters) (*layout, error) {
+	if lay, ok := l[t]; ok {
+		return lay, nil
+	}
+	info, err := structinfo.ExtractType(t, "fixed")
+	if err != nil {
+		return nil, err
+	}
+	lay := &layout{}
+	for _, fi := range info.Fields.Exported {
+		if fi.Tag == "" || fi.Tag == "-" {
+			continue
+		}
+		f, opts, err := parseTag(fi.Tag)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", fi.Name, err)
+		}
+		opts.Converters = convs
+		if f.codec, err = tsv.NewValueCodec(opts); err != nil {
+			return nil, fmt.Errorf("field %s: %w", fi.Name, err)
+		}
+		f.index, f.name = fi.Index, fi.Name
+		if end := f.start + f.len; end > lay.width {
+			lay.width = end
+		}
+		lay.fields = append(lay.fields, f)
+	}
+	l[t] = lay
+	return lay, nil
+}
+
+// parseTag parses a fixed struct tag, made of comma separated options in the
+// form key=value or key:value
+func parseTag(tag string) (field, tsv.ValueOptions, error) {
+	f := field{start: -1, pad: ' '}
+	var opts tsv.ValueOptions
+	for _, opt := range strings.Split(tag, ",") {
+		if opt == "" {
+			continue
+		}
+		key, val := opt, ""
+		if i := strings.IndexAny(opt, ":="); i >= 0 {
+			key, val = opt[:i], opt[i+1:]
+		}
+		var err error
+		switch key {
+		case "start":
+			f.start, err = strconv.Atoi(val)
+		case "len":
+			f.len, err = strconv.Atoi(val)
+		case "align":
+			switch val {
+			case "left":
+				f.align = AlignLeft
+			case "right":
+				f.align = AlignRight
+			default:
+				err = fmt.Errorf("unknown alignment %q", val)
+			}
+		case "pad":
+			r, size := utf8.DecodeRuneInString(val)
+			if size == 0 || size != len(val) {
+				err = fmt.Errorf("pad must be a single character, got %q", val)
+			}
+			f.pad = r
+		case "layout":
+			opts.Layout = val
+		case "sep":
+			opts.Sep = val
+		case "conv":
+			opts.Conv = val
+		default:
+			err = fmt.Errorf("unknown option %q", key)
+		}
+		if err != nil {
+			return f, opts, fmt.Errorf("%w: %v", ErrInvalidTag, err)
+		}
+	}
+	if f.start < 0 || f.len <= 0 {
+		return f, opts, fmt.Errorf("%w: start and a positive len are required", ErrInvalidTag)
+	}
+	return f, opts, nil
+}
+
+// Reader reads fixed-width records
+type Reader struct {
+	// RecordLen, if positive, is the length of the records of inputs that
+	// don't separate them with line breaks. Otherwise each line is a record
+	RecordLen int
+
+	// Converters holds the custom converters used by Decode. If nil,
+	// tsv.DefaultConverters is used. It must be set before the first call to Decode
+	Converters *tsv.Converters
+
+	r       *bufio.Reader
+	line    int
+	rec     string
+	err     error
+	layouts layouts
+}
+
+// NewReader returns a new Reader that reads from r
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Read reads the next record
+func (r *Reader) Read() (string, error) {
+	rec, err := r.read()
+	r.rec, r.err = rec, err
+	return rec, err
+}
+
+func (r *Reader) read() (string, error) {
+	if r.RecordLen > 0 {
+		buf := make([]byte, r.RecordLen)
+		n, err := io.ReadFull(r.r, buf)
+		if err == io.ErrUnexpectedEOF {
+			err = nil
+		}
+		if err != nil {
+			return "", err
+		}
+		r.line++
+		return string(buf[:n]), nil
+	}
+	for {
+		line, err := r.r.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", err
+		}
+		r.line++
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		if line != "" {
+			// empty lines are skipped
+			return line, nil
+		}
+	}
+}
+
+// Next reads the next record. It returns false when there are no more
+// records or an error happens, use Err to tell them apart
+func (r *Reader) Next() bool {
+	_, err := r.Read()
+	return err == nil
+}
+
+// Err returns the error that made Next return false, or nil if the end of the data was reached
+func (r *Reader) Err() error {
+	if r.err == io.EOF {
+		return nil
+	}
+	return r.err
+}
+
+// Line returns the line, or record number, of the current record
+func (r *Reader) Line() int {
+	return r.line
+}
+
+// Decode decodes the current record into the dest struct. Values that can't
+// be converted are reported as a *ParseError
+func (r *Reader) Decode(dest interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	if r.rec == "" {
+		return ErrEmptyRecord
+	}
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return structinfo.ErrInvalidType
+	}
+	v = v.Elem()
+	if r.layouts == nil {
+		r.layouts = make(layouts)
+	}
+	lay, err := r.layouts.get(v.Type(), r.Converters)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range lay.fields {
+		raw := slice(r.rec, f.start, f.len)
+		val := f.trim(raw)
+		fv := v.Field(f.index)
+		if val == "" {
+			fv.Set(reflect.Zero(fv.Type()))
+			continue
+		}
+		if err := f.codec.Decode(val, fv); err != nil {
+			if err == tsv.ErrUnsuportedFieldType {
+				return err
+			}
+			return &ParseError{Line: r.line, Start: f.start, Field: f.name, Value: raw, Err: err}
+		}
+	}
+	return nil
+}
+
+// slice returns the n bytes of s from start, or the ones available
+func slice(s string, start, n int) string {
+	if start >= len(s) {
+		return ""
+	}
+	if start+n > len(s) {
+		return s[start:]
+	}
+	return s[start : start+n]
+}
+
+// trim removes the padding of a value
+func (f field) trim(val string) string {
+	if f.align == AlignRight {
+		return strings.TrimLeft(val, string(f.pad))
+	}
+	return strings.TrimRight(val, string(f.pad))
+}
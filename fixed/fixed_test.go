@@ -0,0 +1,117 @@
+package fixed_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/syb-devs/gotools/fixed"
+	"github.com/syb-devs/gotools/tsv"
+)
+
+type movement struct {
+	Account string    `fixed:"start=0,len=8"`
+	Amount  int64     `fixed:"start=8,len=10,align=right,pad=0"`
+	Date    time.Time `fixed:"start=18,len=8,layout=20060102"`
+	Rate    float64   `fixed:"start=26,len=6,align=right,conv=eurodecimal"`
+	Flags   []string  `fixed:"start=33,len=5,sep=|"`
+	Note    *string   `fixed:"start=38,len=10"`
+	Ignored string
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+const movements = `ACC001  000000150020240131   1,5 a|b  transfer
+ACC002  -00000004220240201     0
+ACC003
+`
+
+var wantMovements = []movement{
+	{
+		Account: "ACC001",
+		Amount:  1500,
+		Date:    time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+		Rate:    1.5,
+		Flags:   []string{"a", "b"},
+		Note:    strPtr("transfer"),
+	},
+	{
+		Account: "ACC002",
+		Amount:  -42,
+		Date:    time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+	},
+	{Account: "ACC003"},
+}
+
+func TestDecode(t *testing.T) {
+	r := fixed.NewReader(strings.NewReader(movements))
+	var have []movement
+	for r.Next() {
+		var m movement
+		if err := r.Decode(&m); err != nil {
+			t.Fatalf("line %d: unexpected error: %v", r.Line(), err)
+		}
+		have = append(have, m)
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(have, wantMovements) {
+		t.Errorf("mismatch\nhave %#+v\nwant %#+v", have, wantMovements)
+	}
+}
+
+func TestDecodeRecordLen(t *testing.T) {
+	r := fixed.NewReader(strings.NewReader("ACC001  0000000001ACC002  0000000002"))
+	r.RecordLen = 18
+	var amounts []int64
+	for r.Next() {
+		var m movement
+		if err := r.Decode(&m); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		amounts = append(amounts, m.Amount)
+	}
+	if want := []int64{1, 2}; !reflect.DeepEqual(amounts, want) {
+		t.Errorf("mismatch\nhave %v\nwant %v", amounts, want)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	r := fixed.NewReader(strings.NewReader("ACC001  00000001x5"))
+	r.Next()
+	var perr *fixed.ParseError
+	err := r.Decode(&movement{})
+	if !errors.As(err, &perr) || perr.Field != "Amount" || perr.Start != 8 || perr.Value != "00000001x5" {
+		t.Errorf("expecting a parse error in Amount, got %v", err)
+	}
+
+	var badTags = []interface{}{
+		&struct {
+			A string `fixed:"len=3"`
+		}{},
+		&struct {
+			A string `fixed:"start=0,len=3,align=center"`
+		}{},
+		&struct {
+			A string `fixed:"start=0,len=3,pad=ab"`
+		}{},
+		&struct {
+			A string `fixed:"start=0,len=3,width=2"`
+		}{},
+	}
+	for i, dest := range badTags {
+		if err := r.Decode(dest); !errors.Is(err, fixed.ErrInvalidTag) {
+			t.Errorf("#%d: expecting an invalid tag error, got %v", i, err)
+		}
+	}
+	if err := r.Decode(&struct {
+		A string `fixed:"start=0,len=3,conv=nope"`
+	}{}); !errors.Is(err, tsv.ErrUnknownConverter) {
+		t.Errorf("expecting an unknown converter error, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package fixed
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/syb-devs/gotools/structinfo"
+	"github.com/syb-devs/gotools/tsv"
+)
+
+// Writer writes fixed-width records
+type Writer struct {
+	// NoNewline makes the writer omit the line break after each record, for
+	// inputs read with a RecordLen
+	NoNewline bool
+
+	// Converters holds the custom converters used by Encode. If nil,
+	// tsv.DefaultConverters is used. It must be set before the first call to Encode
+	Converters *tsv.Converters
+
+	w       *bufio.Writer
+	layouts layouts
+}
+
+// NewWriter returns a new Writer that writes to w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// Encode writes the src struct as a record using the same tags understood by
+// Reader.Decode. The record is as wide as the end of its last field, and the
+// gaps between fields are filled with spaces. Values longer than their field
+// fail with ErrOverflow
+func (w *Writer) Encode(src interface{}) error {
+	v := reflect.ValueOf(src)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return structinfo.ErrInvalidType
+	}
+	if w.layouts == nil {
+		w.layouts = make(layouts)
+	}
+	lay, err := w.layouts.get(v.Type(), w.Converters)
+	if err != nil {
+		return err
+	}
+
+	rec := []byte(strings.Repeat(" ", lay.width))
+	for _, f := range lay.fields {
+		val, err := f.codec.Encode(v.Field(f.index))
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.name, err)
+		}
+		val, err = f.fit(val)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.name, err)
+		}
+		copy(rec[f.start:], val)
+	}
+	if !w.NoNewline {
+		rec = append(rec, '\n')
+	}
+	_, err = w.w.Write(rec)
+	return err
+}
+
+// Flush writes any buffered data to the underlying io.Writer
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// fit pads val to the length of the field, following its alignment
+func (f field) fit(val string) (string, error) {
+	if len(val) > f.len {
+		return "", fmt.Errorf("%w: %q is longer than %d", ErrOverflow, val, f.len)
+	}
+	n := (f.len - len(val)) / utf8.RuneLen(f.pad)
+	padding := strings.Repeat(string(f.pad), n)
+	// multibyte pad characters may leave a gap, filled with spaces
+	padding += strings.Repeat(" ", f.len-len(val)-len(padding))
+	if f.align == AlignRight {
+		if f.pad == '0' && strings.HasPrefix(val, "-") {
+			// zero padded negative numbers keep the sign first
+			return "-" + padding + val[1:], nil
+		}
+		return padding + val, nil
+	}
+	return val + padding, nil
+}
@@ -0,0 +1,32 @@
+package fixed_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/syb-devs/gotools/fixed"
+)
+
+func TestEncode(t *testing.T) {
+	var buf bytes.Buffer
+	w := fixed.NewWriter(&buf)
+	for _, m := range wantMovements {
+		if err := w.Encode(m); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	w.Flush()
+	want := `ACC001  000000150020240131   1,5 a|b  transfer  
+ACC002  -00000004220240201     0                
+ACC003  000000000000010101     0                
+`
+	if buf.String() != want {
+		t.Errorf("mismatch\nhave %q\nwant %q", buf.String(), want)
+	}
+
+	err := w.Encode(movement{Account: "ACCOUNT01"})
+	if !errors.Is(err, fixed.ErrOverflow) {
+		t.Errorf("expecting an overflow error, got %v", err)
+	}
+}
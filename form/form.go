@@ -3,8 +3,10 @@ package form
 import (
 	"net/http"
 	"net/url"
+	"sort"
 
 	"github.com/syb-devs/gotools/strings"
+	"github.com/syb-devs/gotools/validate"
 	"gopkg.in/mgo.v2/bson"
 )
 
@@ -50,3 +52,32 @@ func (f Form) ParseBool(field string) (bool, error) {
 	val := f.GetOne(field)
 	return strings.ParseBool(val)
 }
+
+// Validate checks the value of each field against its rules, written as in
+// a validate struct tag, like "required,min=3". Form values are strings, so
+// min and max bound their length, unless the number rule is given, like in
+// "number,min=18", which bounds their value as for numeric struct fields.
+// The broken rules are returned as a validate.Errors list, sorted by field name
+func (f Form) Validate(rules map[string]string) error {
+	fields := make([]string, 0, len(rules))
+	for field := range rules {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var errs validate.Errors
+	for _, field := range fields {
+		rs, err := validate.Parse(rules[field])
+		if err != nil {
+			return err
+		}
+		val := f.GetOne(field)
+		if err := rs.ValidateString(val); err != nil {
+			errs = append(errs, &validate.FieldError{Field: field, Value: val, Err: err})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
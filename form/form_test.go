@@ -0,0 +1,74 @@
+package form_test
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/syb-devs/gotools/form"
+	"github.com/syb-devs/gotools/validate"
+)
+
+func newForm(t *testing.T, values url.Values) *form.Form {
+	r, err := http.NewRequest("POST", "/", strings.NewReader(values.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	f, err := form.NewForm(*r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestValidate(t *testing.T) {
+	f := newForm(t, url.Values{"name": {"jo"}, "age": {"9"}, "code": {"123456789012345678"}, "role": {"editor"}})
+	rules := map[string]string{
+		"name":  "required,min=3",
+		"age":   "number,min=18,max=130",
+		"code":  "min=18",
+		"role":  "oneof=admin|editor",
+		"email": "required",
+	}
+	err := f.Validate(rules)
+	var errs validate.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expecting validation errors, got %v", err)
+	}
+	want := []struct {
+		field string
+		err   error
+	}{
+		{"age", validate.ErrMin},
+		{"email", validate.ErrRequired},
+		{"name", validate.ErrMin},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expecting %d errors, got %v", len(want), errs)
+	}
+	for i, w := range want {
+		if errs[i].Field != w.field || !errors.Is(errs[i], w.err) {
+			t.Errorf("#%d: mismatch\nhave %s: %v\nwant %s: %v", i, errs[i].Field, errs[i].Err, w.field, w.err)
+		}
+	}
+
+	f = newForm(t, url.Values{"name": {"john"}, "age": {"42"}, "email": {"john@example.com"}})
+	delete(rules, "code")
+	delete(rules, "role")
+	if err := f.Validate(rules); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := f.Validate(map[string]string{"age": "number"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	err = newForm(t, url.Values{"age": {"old"}}).Validate(map[string]string{"age": "number"})
+	if !errors.As(err, &errs) || len(errs) != 1 || !errors.Is(errs[0], validate.ErrNumber) {
+		t.Errorf("expecting a not a number error, got %v", err)
+	}
+	if err := f.Validate(map[string]string{"age": "unknown"}); !errors.Is(err, validate.ErrInvalidRule) {
+		t.Errorf("expecting an invalid rule error, got %v", err)
+	}
+}
@@ -0,0 +1,292 @@
+package sheet
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/syb-devs/gotools/tsv"
+)
+
+const (
+	tableNS = "urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	textNS  = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+
+	// maxSpaces caps the spaces of a text:s element
+	maxSpaces = 1024
+)
+
+// odsPara is a text:p paragraph, whose text may be split in spans and use
+// elements for spaces, tabs and line breaks
+type odsPara string
+
+func (p *odsPara) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var b strings.Builder
+	for depth := 1; depth > 0; {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			b.Write(t)
+		case xml.StartElement:
+			depth++
+			if t.Name.Space != textNS {
+				continue
+			}
+			switch t.Name.Local {
+			case "s":
+				n := 1
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "c" {
+						// malformed counts are taken as zero
+						n, _ = strconv.Atoi(attr.Value)
+					}
+				}
+				if n < 0 {
+					n = 0
+				} else if n > maxSpaces {
+					n = maxSpaces
+				}
+				b.WriteString(strings.Repeat(" ", n))
+			case "tab":
+				b.WriteByte('\t')
+			case "line-break":
+				b.WriteByte('\n')
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+	*p = odsPara(b.String())
+	return nil
+}
+
+type odsCellXML struct {
+	Repeat    int       `xml:"urn:oasis:names:tc:opendocument:xmlns:table:1.0 number-columns-repeated,attr"`
+	Type      string    `xml:"urn:oasis:names:tc:opendocument:xmlns:office:1.0 value-type,attr"`
+	Value     string    `xml:"urn:oasis:names:tc:opendocument:xmlns:office:1.0 value,attr"`
+	DateValue string    `xml:"urn:oasis:names:tc:opendocument:xmlns:office:1.0 date-value,attr"`
+	BoolValue string    `xml:"urn:oasis:names:tc:opendocument:xmlns:office:1.0 boolean-value,attr"`
+	Paras     []odsPara `xml:"urn:oasis:names:tc:opendocument:xmlns:text:1.0 p"`
+}
+
+func (c odsCellXML) text() string {
+	paras := make([]string, len(c.Paras))
+	for i, p := range c.Paras {
+		paras[i] = string(p)
+	}
+	return strings.Join(paras, "\n")
+}
+
+// value returns the cell value. Numbers are taken as displayed when that
+// keeps leading zeros, and as stored otherwise
+func (c odsCellXML) value() string {
+	switch c.Type {
+	case "float", "percentage", "currency":
+		if text := c.text(); isDigits(text) && strings.HasPrefix(text, "0") {
+			if f, err := strconv.ParseFloat(text, 64); err == nil && strconv.FormatFloat(f, 'f', -1, 64) == c.Value {
+				return text
+			}
+		}
+		return c.Value
+	case "date":
+		for _, layout := range []string{"2006-01-02T15:04:05.999999999", "2006-01-02"} {
+			if t, err := time.Parse(layout, c.DateValue); err == nil {
+				return t.Format(tsv.DefaultTimeLayout)
+			}
+		}
+		return c.DateValue
+	case "boolean":
+		return c.BoolValue
+	}
+	return c.text()
+}
+
+type odsRowXML struct {
+	Repeat int `xml:"urn:oasis:names:tc:opendocument:xmlns:table:1.0 number-rows-repeated,attr"`
+	Cells  []struct {
+		XMLName xml.Name
+		odsCellXML
+	} `xml:",any"`
+}
+
+type odsBook struct {
+	zr    *zip.Reader
+	names []string
+}
+
+func openODS(zr *zip.Reader) (*odsBook, error) {
+	b := &odsBook{zr: zr}
+	rc, d, err := b.content()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return b, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := tok.(xml.StartElement); ok && isTable(start) {
+			b.names = append(b.names, attr(start, tableNS, "name"))
+			if err := d.Skip(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (b *odsBook) content() (io.ReadCloser, *xml.Decoder, error) {
+	f := findFile(b.zr, "content.xml")
+	if f == nil {
+		return nil, nil, ErrUnknownFormat
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	return rc, xml.NewDecoder(rc), nil
+}
+
+func (b *odsBook) sheets() []string {
+	return b.names
+}
+
+func (b *odsBook) open(index int) (rowReader, error) {
+	rc, d, err := b.content()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; ; {
+		tok, err := d.Token()
+		if err != nil {
+			rc.Close()
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || !isTable(start) {
+			continue
+		}
+		if i == index {
+			return &odsRows{rc: rc, d: d}, nil
+		}
+		if err := d.Skip(); err != nil {
+			rc.Close()
+			return nil, err
+		}
+		i++
+	}
+}
+
+// odsRows streams the rows of a table, from right after its start element
+type odsRows struct {
+	rc     io.ReadCloser
+	d      *xml.Decoder
+	line   int
+	repeat int      // times left to return the last row
+	last   []string // last row returned
+}
+
+func (r *odsRows) next() ([]string, int, error) {
+	if r.repeat > 0 {
+		r.repeat--
+		r.line++
+		return append([]string{}, r.last...), r.line, nil
+	}
+	for {
+		tok, err := r.d.Token()
+		if err != nil {
+			return nil, r.line, err
+		}
+		if end, ok := tok.(xml.EndElement); ok && end.Name.Space == tableNS && end.Name.Local == "table" {
+			return nil, r.line, io.EOF
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Space != tableNS || start.Name.Local != "table-row" {
+			continue
+		}
+		var row odsRowXML
+		if err := r.d.DecodeElement(&row, &start); err != nil {
+			return nil, r.line, err
+		}
+		cells, err := r.cells(row)
+		r.line++
+		if err != nil {
+			return nil, r.line, err
+		}
+		if len(cells) == 0 {
+			// repeated empty rows often fill the sheet up to its maximum size
+			if row.Repeat > 1 {
+				r.line += row.Repeat - 1
+			}
+			return cells, r.line, nil
+		}
+		if row.Repeat > 1 {
+			r.repeat, r.last = row.Repeat-1, cells
+		}
+		return cells, r.line, nil
+	}
+}
+
+// cells returns the cells of the row. Repeated empty cells are only added
+// when followed by a non empty one, as they often fill the row up to the
+// maximum width of the sheet
+func (r *odsRows) cells(row odsRowXML) ([]string, error) {
+	var cells []string
+	empty := 0
+	for _, c := range row.Cells {
+		if c.XMLName.Space != tableNS || (c.XMLName.Local != "table-cell" && c.XMLName.Local != "covered-table-cell") {
+			continue
+		}
+		n := c.Repeat
+		if n < 1 {
+			n = 1
+		} else if n > maxColumns {
+			// keeps the counts below from overflowing
+			n = maxColumns + 1
+		}
+		val := c.value()
+		if val == "" {
+			empty += n
+			if empty > maxColumns {
+				empty = maxColumns
+			}
+			continue
+		}
+		if len(cells)+empty+n > maxColumns {
+			return nil, ErrTooWide
+		}
+		for ; empty > 0; empty-- {
+			cells = append(cells, "")
+		}
+		for i := 0; i < n; i++ {
+			cells = append(cells, val)
+		}
+	}
+	return cells, nil
+}
+
+func (r *odsRows) Close() error {
+	return r.rc.Close()
+}
+
+func isTable(start xml.StartElement) bool {
+	return start.Name.Space == tableNS && start.Name.Local == "table"
+}
+
+func attr(start xml.StartElement, space, local string) string {
+	for _, a := range start.Attr {
+		if a.Name.Space == space && a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,207 @@
+/*
+Package sheet reads the sheets of XLSX and ODS workbooks as a tsv.Reader, so
+rows are decoded into structs with the same tsv tags used for TSV files:
+
+	wb, err := sheet.Open("prices.xlsx")
+	...
+	defer wb.Close()
+	r, err := wb.Sheet("Prices")
+	...
+	r.ReadHeader()
+	prices, err := tsv.ReadAll[Price](r)
+
+Cells are read as the text they hold, so codes like 00123 keep their leading
+zeros. Numbers formatted with leading zeros, like Excel's 00000 format, are
+padded as displayed. Dates are written in the tsv.DefaultTimeLayout, RFC 3339,
+so they decode into time.Time fields without a layout tag option. Booleans are
+written as true and false.
+
+Rows are padded with empty cells up to the width of the first one, which is
+usually the header, and empty rows are skipped.
+*/
+package sheet
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/syb-devs/gotools/tsv"
+)
+
+var (
+	// ErrUnknownFormat is returned for files that are neither XLSX nor ODS workbooks
+	ErrUnknownFormat = errors.New("not an XLSX or ODS workbook")
+
+	// ErrSheetNotFound is returned when the workbook has no such sheet
+	ErrSheetNotFound = errors.New("sheet not found")
+
+	// ErrTooWide is returned for rows with cells past the maximum number of columns
+	ErrTooWide = errors.New("row wider than 16384 columns")
+)
+
+const (
+	odsMimeType = "application/vnd.oasis.opendocument.spreadsheet"
+
+	// maxColumns is the maximum number of columns of a row, up to XFD as in Excel
+	maxColumns = 16384
+)
+
+// Workbook is an XLSX or ODS workbook
+type Workbook struct {
+	zr     *zip.Reader
+	closer io.Closer
+	book   book
+}
+
+// book reads the sheets of a workbook format
+type book interface {
+	sheets() []string
+	open(index int) (rowReader, error)
+}
+
+// rowReader reads the rows of a sheet. Cells missing from the sheet are
+// returned as empty strings, but rows may have any width
+type rowReader interface {
+	next() (cells []string, line int, err error)
+	io.Closer
+}
+
+// Open opens the workbook file
+func Open(name string) (*Workbook, error) {
+	zr, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, err
+	}
+	wb, err := newWorkbook(&zr.Reader)
+	if err != nil {
+		zr.Close()
+		return nil, err
+	}
+	wb.closer = zr
+	return wb, nil
+}
+
+// NewWorkbook reads a workbook of the given size from r
+func NewWorkbook(r io.ReaderAt, size int64) (*Workbook, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return newWorkbook(zr)
+}
+
+func newWorkbook(zr *zip.Reader) (*Workbook, error) {
+	wb := &Workbook{zr: zr}
+	var err error
+	switch {
+	case findFile(zr, "xl/workbook.xml") != nil:
+		wb.book, err = openXLSX(zr)
+	case isODS(zr):
+		wb.book, err = openODS(zr)
+	default:
+		err = ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	return wb, nil
+}
+
+// Close closes the workbook file, if it was opened by Open
+func (wb *Workbook) Close() error {
+	if wb.closer == nil {
+		return nil
+	}
+	return wb.closer.Close()
+}
+
+// Sheets returns the names of the sheets, in workbook order
+func (wb *Workbook) Sheets() []string {
+	return wb.book.sheets()
+}
+
+// Sheet returns a Reader for the sheet with the given name
+func (wb *Workbook) Sheet(name string) (*tsv.Reader, error) {
+	for i, sheet := range wb.book.sheets() {
+		if sheet == name {
+			return wb.SheetIndex(i)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrSheetNotFound, name)
+}
+
+// SheetIndex returns a Reader for the sheet at index i, starting at 0.
+// The Reader should be closed once done
+func (wb *Workbook) SheetIndex(i int) (*tsv.Reader, error) {
+	if i < 0 || i >= len(wb.book.sheets()) {
+		return nil, fmt.Errorf("%w: index %d", ErrSheetNotFound, i)
+	}
+	rows, err := wb.book.open(i)
+	if err != nil {
+		return nil, err
+	}
+	return tsv.NewSourceReader(&source{rows: rows}), nil
+}
+
+// source is the tsv.RowSource of a sheet. It skips empty rows, and pads the
+// rest to the width of the first one
+type source struct {
+	rows  rowReader
+	width int
+}
+
+func (s *source) ReadRow() ([]string, int, error) {
+	for {
+		cells, line, err := s.rows.next()
+		if err != nil {
+			return nil, line, err
+		}
+		n := len(cells)
+		for n > 0 && cells[n-1] == "" {
+			n--
+		}
+		if n == 0 {
+			continue
+		}
+		if s.width == 0 {
+			s.width = n
+		}
+		if n < s.width {
+			n = s.width
+		}
+		for len(cells) < n {
+			cells = append(cells, "")
+		}
+		return cells[:n], line, nil
+	}
+}
+
+func (s *source) Close() error {
+	return s.rows.Close()
+}
+
+func findFile(zr *zip.Reader, name string) *zip.File {
+	for _, f := range zr.File {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func isODS(zr *zip.Reader) bool {
+	f := findFile(zr, "mimetype")
+	if f == nil {
+		return false
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return false
+	}
+	defer rc.Close()
+	mime, err := io.ReadAll(io.LimitReader(rc, 128))
+	return err == nil && strings.TrimSpace(string(mime)) == odsMimeType
+}
@@ -0,0 +1,257 @@
+package sheet_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/syb-devs/gotools/sheet"
+	"github.com/syb-devs/gotools/tsv"
+)
+
+type product struct {
+	Code    string    `tsv:"code"`
+	Name    string    `tsv:"name"`
+	Price   float64   `tsv:"price"`
+	Zip     string    `tsv:"zip"`
+	Since   time.Time `tsv:"since"`
+	Active  bool      `tsv:"active"`
+	Comment string    `tsv:"comment"`
+}
+
+var wantProducts = []product{
+	{Code: "00123", Name: "Red apple", Price: 1.5, Zip: "08001", Since: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), Active: true, Comment: "fresh"},
+	{Code: "00124", Name: "Pear", Price: 2, Zip: "28001", Since: time.Date(2023, 12, 1, 12, 30, 0, 0, time.UTC)},
+}
+
+func zipFile(t *testing.T, files [][2]string) *bytes.Reader {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.Create(f[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(f[1]))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+const xlsxNS = `xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"`
+
+func xlsxWorkbook(t *testing.T) *bytes.Reader {
+	return zipFile(t, [][2]string{
+		{"xl/workbook.xml", `<?xml version="1.0"?><workbook ` + xlsxNS + `><sheets>
+			<sheet name="Notes" sheetId="1" r:id="rId2"/>
+			<sheet name="Products" sheetId="2" r:id="rId1"/>
+		</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0"?><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+			<Relationship Id="rId1" Target="worksheets/sheet1.xml"/>
+			<Relationship Id="rId2" Target="/xl/worksheets/sheet2.xml"/>
+		</Relationships>`},
+		{"xl/styles.xml", `<?xml version="1.0"?><styleSheet ` + xlsxNS + `>
+			<numFmts><numFmt numFmtId="164" formatCode="00000"/><numFmt numFmtId="165" formatCode="dd/mm/yyyy\ hh:mm"/></numFmts>
+			<cellXfs><xf numFmtId="0"/><xf numFmtId="14"/><xf numFmtId="164"/><xf numFmtId="165"/></cellXfs>
+		</styleSheet>`},
+		{"xl/sharedStrings.xml", `<?xml version="1.0"?><sst ` + xlsxNS + `>
+			<si><t>code</t></si><si><t>name</t></si><si><t>price</t></si><si><t>zip</t></si>
+			<si><t>since</t></si><si><t>active</t></si><si><t>comment</t></si>
+			<si><t>00123</t></si><si><r><t>Red </t></r><r><t>apple</t></r></si>
+		</sst>`},
+		{"xl/worksheets/sheet1.xml", `<?xml version="1.0"?><worksheet ` + xlsxNS + `><sheetData>
+			<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c>
+				<c r="D1" t="s"><v>3</v></c><c r="E1" t="s"><v>4</v></c><c r="F1" t="s"><v>5</v></c><c r="G1" t="s"><v>6</v></c></row>
+			<row r="2"><c r="A2" t="s"><v>7</v></c><c r="B2" t="s"><v>8</v></c><c r="C2"><v>1.5</v></c>
+				<c r="D2" s="2"><v>8001</v></c><c r="E2" s="1"><v>45322</v></c><c r="F2" t="b"><v>1</v></c>
+				<c r="G2" t="inlineStr"><is><t>fresh</t></is></c></row>
+			<row r="5"><c r="A5" t="str"><v>00124</v></c><c r="B5" t="inlineStr"><is><t>Pear</t></is></c><c r="C5"><v>2</v></c>
+				<c r="D5" s="2"><v>28001</v></c><c r="E5" s="3"><v>45261.520833333336</v></c><c r="F5" t="b"><v>0</v></c></row>
+		</sheetData></worksheet>`},
+		{"xl/worksheets/sheet2.xml", `<?xml version="1.0"?><worksheet ` + xlsxNS + `><sheetData/></worksheet>`},
+	})
+}
+
+const odsContent = `<?xml version="1.0"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0">
+<office:body><office:spreadsheet>
+<table:table table:name="Notes"><table:table-row><table:table-cell><text:p>n</text:p></table:table-cell></table:table-row></table:table>
+<table:table table:name="Products">
+	<table:table-row>
+		<table:table-cell office:value-type="string"><text:p>code</text:p></table:table-cell>
+		<table:table-cell office:value-type="string"><text:p>name</text:p></table:table-cell>
+		<table:table-cell office:value-type="string"><text:p>price</text:p></table:table-cell>
+		<table:table-cell office:value-type="string"><text:p>zip</text:p></table:table-cell>
+		<table:table-cell office:value-type="string"><text:p>since</text:p></table:table-cell>
+		<table:table-cell office:value-type="string"><text:p>active</text:p></table:table-cell>
+		<table:table-cell office:value-type="string"><text:p>comment</text:p></table:table-cell>
+		<table:table-cell table:number-columns-repeated="1017"/>
+	</table:table-row>
+	<table:table-row>
+		<table:table-cell office:value-type="string"><text:p>00123</text:p></table:table-cell>
+		<table:table-cell office:value-type="string"><text:p>Red<text:s/><text:span>apple</text:span></text:p></table:table-cell>
+		<table:table-cell office:value-type="float" office:value="1.5"><text:p>1,50</text:p></table:table-cell>
+		<table:table-cell office:value-type="float" office:value="8001"><text:p>08001</text:p></table:table-cell>
+		<table:table-cell office:value-type="date" office:date-value="2024-01-31"><text:p>31/01/24</text:p></table:table-cell>
+		<table:table-cell office:value-type="boolean" office:boolean-value="true"><text:p>TRUE</text:p></table:table-cell>
+		<table:table-cell office:value-type="string"><text:p>fresh</text:p></table:table-cell>
+	</table:table-row>
+	<table:table-row table:number-rows-repeated="2"><table:table-cell table:number-columns-repeated="1024"/></table:table-row>
+	<table:table-row>
+		<table:table-cell office:value-type="string"><text:p>00124</text:p></table:table-cell>
+		<table:table-cell office:value-type="string"><text:p>Pear</text:p></table:table-cell>
+		<table:table-cell office:value-type="float" office:value="2"><text:p>2</text:p></table:table-cell>
+		<table:table-cell office:value-type="float" office:value="28001"><text:p>28001</text:p></table:table-cell>
+		<table:table-cell office:value-type="date" office:date-value="2023-12-01T12:30:00"><text:p>01/12/23 12:30</text:p></table:table-cell>
+		<table:table-cell office:value-type="boolean" office:boolean-value="false"><text:p>FALSE</text:p></table:table-cell>
+	</table:table-row>
+	<table:table-row table:number-rows-repeated="1048570"><table:table-cell table:number-columns-repeated="1024"/></table:table-row>
+</table:table>
+</office:spreadsheet></office:body></office:document-content>`
+
+func odsWorkbook(t *testing.T) *bytes.Reader {
+	return zipFile(t, [][2]string{
+		{"mimetype", "application/vnd.oasis.opendocument.spreadsheet"},
+		{"content.xml", odsContent},
+	})
+}
+
+func TestSheets(t *testing.T) {
+	for name, zr := range map[string]*bytes.Reader{"xlsx": xlsxWorkbook(t), "ods": odsWorkbook(t)} {
+		wb, err := sheet.NewWorkbook(zr, zr.Size())
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if want := []string{"Notes", "Products"}; !reflect.DeepEqual(wb.Sheets(), want) {
+			t.Errorf("%s: sheets mismatch\nhave %v\nwant %v", name, wb.Sheets(), want)
+		}
+
+		for _, open := range []func() (*tsv.Reader, error){
+			func() (*tsv.Reader, error) { return wb.Sheet("Products") },
+			func() (*tsv.Reader, error) { return wb.SheetIndex(1) },
+		} {
+			r, err := open()
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", name, err)
+			}
+			r.ReadHeader()
+			have, err := tsv.ReadAll[product](r)
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", name, err)
+			}
+			if !reflect.DeepEqual(have, wantProducts) {
+				t.Errorf("%s: mismatch\nhave %#+v\nwant %#+v", name, have, wantProducts)
+			}
+			if r.Line() != 5 {
+				t.Errorf("%s: expecting the last row at line 5, got %d", name, r.Line())
+			}
+			if err := r.Close(); err != nil {
+				t.Errorf("%s: unexpected error closing: %v", name, err)
+			}
+		}
+
+		if _, err := wb.Sheet("Prices"); !errors.Is(err, sheet.ErrSheetNotFound) {
+			t.Errorf("%s: expecting a sheet not found error, got %v", name, err)
+		}
+		if _, err := wb.SheetIndex(2); !errors.Is(err, sheet.ErrSheetNotFound) {
+			t.Errorf("%s: expecting a sheet not found error, got %v", name, err)
+		}
+	}
+
+	zr := zipFile(t, [][2]string{{"readme.txt", "hello"}})
+	if _, err := sheet.NewWorkbook(zr, zr.Size()); err != sheet.ErrUnknownFormat {
+		t.Errorf("expecting an unknown format error, got %v", err)
+	}
+}
+
+// odsTable returns an ODS workbook with a single table with the given rows
+func odsTable(t *testing.T, rows string) *bytes.Reader {
+	return zipFile(t, [][2]string{
+		{"mimetype", "application/vnd.oasis.opendocument.spreadsheet"},
+		{"content.xml", `<?xml version="1.0"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0">
+<office:body><office:spreadsheet><table:table table:name="Sheet1">` + rows + `</table:table></office:spreadsheet></office:body></office:document-content>`},
+	})
+}
+
+func readSheet(t *testing.T, zr *bytes.Reader) ([][]string, error) {
+	wb, err := sheet.NewWorkbook(zr, zr.Size())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r, err := wb.SheetIndex(0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Close()
+	var rows [][]string
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return rows, err
+		}
+		rows = append(rows, row)
+	}
+}
+
+func TestODSSpaces(t *testing.T) {
+	zr := odsTable(t, `<table:table-row>
+		<table:table-cell><text:p>a<text:s text:c="-1"/>b<text:s text:c="x"/>c<text:s text:c="2"/>d</text:p></table:table-cell>
+		<table:table-cell><text:p>e<text:s text:c="1000000000"/></text:p></table:table-cell>
+	</table:table-row>`)
+	have, err := readSheet(t, zr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(have) != 1 || len(have[0]) != 2 || have[0][0] != "abc  d" || len(have[0][1]) != 1025 {
+		t.Errorf("mismatch\nhave %q", have)
+	}
+}
+
+func TestTooWide(t *testing.T) {
+	xlsx := func(cells string) *bytes.Reader {
+		return zipFile(t, [][2]string{
+			{"xl/workbook.xml", `<?xml version="1.0"?><workbook ` + xlsxNS + `><sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+			{"xl/_rels/workbook.xml.rels", `<?xml version="1.0"?><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+				<Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`},
+			{"xl/worksheets/sheet1.xml", `<?xml version="1.0"?><worksheet ` + xlsxNS + `><sheetData><row r="1">` + cells + `</row></sheetData></worksheet>`},
+		})
+	}
+	cell := `<table:table-cell office:value-type="string"><text:p>x</text:p></table:table-cell>`
+	tests := []struct {
+		zr      *bytes.Reader
+		width   int
+		tooWide bool
+	}{
+		{xlsx(`<c r="XFD1" t="inlineStr"><is><t>x</t></is></c>`), 16384, false},
+		{xlsx(`<c r="XFE1" t="inlineStr"><is><t>x</t></is></c>`), 0, true},
+		{xlsx(`<c r="ZZZZZZZZZZZZZZ1" t="inlineStr"><is><t>x</t></is></c>`), 0, true},
+		{odsTable(t, `<table:table-row><table:table-cell table:number-columns-repeated="16383"/>`+cell+`</table:table-row>`), 16384, false},
+		{odsTable(t, `<table:table-row><table:table-cell table:number-columns-repeated="16384"/>`+cell+`</table:table-row>`), 0, true},
+		{odsTable(t, `<table:table-row><table:table-cell office:value-type="string" table:number-columns-repeated="2147483647"><text:p>x</text:p></table:table-cell></table:table-row>`), 0, true},
+		{odsTable(t, `<table:table-row>`+cell+`<table:table-cell table:number-columns-repeated="9223372036854775807"/></table:table-row>`), 1, false},
+	}
+	for i, tc := range tests {
+		have, err := readSheet(t, tc.zr)
+		if tc.tooWide {
+			if !errors.Is(err, sheet.ErrTooWide) {
+				t.Errorf("#%d: expecting a too wide error, got %v", i, err)
+			}
+			continue
+		}
+		if err != nil || len(have) != 1 || len(have[0]) != tc.width {
+			t.Errorf("#%d: expecting a row with %d cells, got %d rows, %v", i, tc.width, len(have), err)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package sheet
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/syb-devs/gotools/tsv"
+)
+
+type xlsxWorkbookXML struct {
+	Pr struct {
+		Date1904 bool `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelsXML struct {
+	Rels []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxStylesXML struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+// xlsxText is the text of a shared or inline string, which may be split in runs
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, r := range t.Runs {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+type xlsxRowXML struct {
+	Num   int `xml:"r,attr"`
+	Cells []struct {
+		Ref    string   `xml:"r,attr"`
+		Type   string   `xml:"t,attr"`
+		Style  int      `xml:"s,attr"`
+		Value  string   `xml:"v"`
+		Inline xlsxText `xml:"is"`
+	} `xml:"c"`
+}
+
+// cellFormat tells how the numbers of a cell style are displayed
+type cellFormat struct {
+	date  bool
+	zeros int // width of formats like 00000, which pad numbers with zeros
+}
+
+type xlsxBook struct {
+	zr       *zip.Reader
+	names    []string
+	paths    []string
+	strings  []string
+	formats  []cellFormat
+	date1904 bool
+}
+
+func openXLSX(zr *zip.Reader) (*xlsxBook, error) {
+	b := &xlsxBook{zr: zr}
+	var wb xlsxWorkbookXML
+	if err := decodeFile(zr, "xl/workbook.xml", &wb); err != nil {
+		return nil, err
+	}
+	var rels xlsxRelsXML
+	if err := decodeFile(zr, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string, len(rels.Rels))
+	for _, rel := range rels.Rels {
+		if strings.HasPrefix(rel.Target, "/") {
+			targets[rel.ID] = strings.TrimPrefix(rel.Target, "/")
+		} else {
+			targets[rel.ID] = path.Join("xl", rel.Target)
+		}
+	}
+	b.date1904 = wb.Pr.Date1904
+	for _, sheet := range wb.Sheets {
+		b.names = append(b.names, sheet.Name)
+		b.paths = append(b.paths, targets[sheet.RID])
+	}
+
+	if findFile(zr, "xl/sharedStrings.xml") != nil {
+		var sst struct {
+			Items []xlsxText `xml:"si"`
+		}
+		if err := decodeFile(zr, "xl/sharedStrings.xml", &sst); err != nil {
+			return nil, err
+		}
+		b.strings = make([]string, len(sst.Items))
+		for i, si := range sst.Items {
+			b.strings[i] = si.String()
+		}
+	}
+
+	if findFile(zr, "xl/styles.xml") != nil {
+		var styles xlsxStylesXML
+		if err := decodeFile(zr, "xl/styles.xml", &styles); err != nil {
+			return nil, err
+		}
+		codes := make(map[int]string, len(styles.NumFmts))
+		for _, f := range styles.NumFmts {
+			codes[f.ID] = f.Code
+		}
+		b.formats = make([]cellFormat, len(styles.CellXfs))
+		for i, xf := range styles.CellXfs {
+			b.formats[i] = numFormat(xf.NumFmtID, codes[xf.NumFmtID])
+		}
+	}
+	return b, nil
+}
+
+func (b *xlsxBook) sheets() []string {
+	return b.names
+}
+
+func (b *xlsxBook) open(index int) (rowReader, error) {
+	f := findFile(b.zr, b.paths[index])
+	if f == nil {
+		return nil, fmt.Errorf("%w: missing part %s", ErrSheetNotFound, b.paths[index])
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &xlsxRows{book: b, rc: rc, d: xml.NewDecoder(rc)}, nil
+}
+
+// xlsxRows streams the rows of a worksheet
+type xlsxRows struct {
+	book *xlsxBook
+	rc   io.ReadCloser
+	d    *xml.Decoder
+	line int
+}
+
+func (r *xlsxRows) next() ([]string, int, error) {
+	for {
+		tok, err := r.d.Token()
+		if err != nil {
+			return nil, r.line, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+		var row xlsxRowXML
+		if err := r.d.DecodeElement(&row, &start); err != nil {
+			return nil, r.line, err
+		}
+		r.line++
+		if row.Num > 0 {
+			r.line = row.Num
+		}
+		var cells []string
+		for _, c := range row.Cells {
+			col := len(cells)
+			if c.Ref != "" {
+				if col, err = colIndex(c.Ref); err != nil {
+					return nil, r.line, err
+				}
+			}
+			if col >= maxColumns {
+				return nil, r.line, fmt.Errorf("cell %s: %w", c.Ref, ErrTooWide)
+			}
+			for len(cells) <= col {
+				cells = append(cells, "")
+			}
+			switch c.Type {
+			case "s":
+				i, err := strconv.Atoi(c.Value)
+				if err != nil || i < 0 || i >= len(r.book.strings) {
+					return nil, r.line, fmt.Errorf("cell %s: invalid shared string %q", c.Ref, c.Value)
+				}
+				cells[col] = r.book.strings[i]
+			case "inlineStr":
+				cells[col] = c.Inline.String()
+			case "b":
+				cells[col] = strconv.FormatBool(c.Value == "1")
+			case "n", "":
+				cells[col] = r.book.number(c.Value, c.Style)
+			default:
+				// str formulas, errors and iso dates are kept as is
+				cells[col] = c.Value
+			}
+		}
+		return cells, r.line, nil
+	}
+}
+
+func (r *xlsxRows) Close() error {
+	return r.rc.Close()
+}
+
+// number formats a numeric cell as displayed by its style, for dates and
+// zero padded numbers, or as stored otherwise
+func (b *xlsxBook) number(val string, style int) string {
+	if val == "" || style < 0 || style >= len(b.formats) {
+		return val
+	}
+	format := b.formats[style]
+	switch {
+	case format.date:
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return val
+		}
+		return serialTime(f, b.date1904).Format(tsv.DefaultTimeLayout)
+	case format.zeros > 0 && isDigits(val):
+		if pad := format.zeros - len(val); pad > 0 {
+			return strings.Repeat("0", pad) + val
+		}
+	}
+	return val
+}
+
+// serialTime converts an Excel serial date, the days since the epoch, to a time
+func serialTime(days float64, date1904 bool) time.Time {
+	// the 1900 system epoch is set back two days: day 1 is 1900-01-01, and
+	// Excel takes 1900 as a leap year
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	ms := math.Round(days * 24 * 60 * 60 * 1000)
+	return epoch.Add(time.Duration(ms) * time.Millisecond).Round(time.Second)
+}
+
+var (
+	// builtinDateFormats are the ids of the built in date and time formats
+	builtinDateFormats = map[int]bool{
+		14: true, 15: true, 16: true, 17: true, 18: true, 19: true, 20: true, 21: true, 22: true,
+		27: true, 28: true, 29: true, 30: true, 31: true, 32: true, 33: true, 34: true, 35: true, 36: true,
+		45: true, 46: true, 47: true, 50: true, 51: true, 52: true, 53: true, 54: true, 55: true,
+		56: true, 57: true, 58: true,
+	}
+	// literalFormat matches the parts of a format code that are not placeholders:
+	// quoted text, escaped characters and sections like [Red] or [$-409]
+	literalFormat = regexp.MustCompile(`"[^"]*"|\\.|\[[^\]]*\]`)
+	zeroFormat    = regexp.MustCompile(`^0+$`)
+)
+
+func numFormat(id int, code string) cellFormat {
+	if builtinDateFormats[id] {
+		return cellFormat{date: true}
+	}
+	if code == "" {
+		return cellFormat{}
+	}
+	if zeroFormat.MatchString(code) {
+		return cellFormat{zeros: len(code)}
+	}
+	// only the format for positive numbers matters
+	code, _, _ = strings.Cut(code, ";")
+	code = strings.ToLower(literalFormat.ReplaceAllString(code, ""))
+	return cellFormat{date: strings.ContainsAny(code, "dmyhs")}
+}
+
+// colIndex returns the column index of a cell reference like B3
+func colIndex(ref string) (int, error) {
+	col := 0
+	for i, r := range ref {
+		switch {
+		case col > maxColumns:
+			// stop before overflowing, the caller rejects the column
+			return col - 1, nil
+		case r >= 'A' && r <= 'Z':
+			col = col*26 + int(r-'A') + 1
+		case r >= 'a' && r <= 'z':
+			col = col*26 + int(r-'a') + 1
+		case i > 0 && r >= '0' && r <= '9':
+			return col - 1, nil
+		default:
+			return 0, fmt.Errorf("invalid cell reference %q", ref)
+		}
+	}
+	if col == 0 {
+		return 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	return col - 1, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// decodeFile unmarshals the XML file of the archive into v
+func decodeFile(zr *zip.Reader, name string, v interface{}) error {
+	f := findFile(zr, name)
+	if f == nil {
+		return fmt.Errorf("%w: missing part %s", ErrUnknownFormat, name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
@@ -19,16 +19,25 @@ type Info struct {
 
 // FieldInfo holds info about a struct field
 type FieldInfo struct {
-	Index    int
-	Name     string
-	Kind     reflect.Kind
-	Exported bool
-	Tag      string
+	Index     int
+	Name      string
+	Kind      reflect.Kind
+	Exported  bool
+	Anonymous bool
+	Tag       string
 }
 
 // Extract returns informatin about a struct extracted using reflection
 func Extract(s interface{}, tagNamespace string) (*Info, error) {
-	t := reflect.TypeOf(s)
+	return ExtractType(reflect.TypeOf(s), tagNamespace)
+}
+
+// ExtractType returns information about a struct type. It's useful to build
+// per-type caches without having a value of the type at hand
+func ExtractType(t reflect.Type, tagNamespace string) (*Info, error) {
+	if t == nil {
+		return nil, ErrInvalidType
+	}
 	// dereference until not a pointer
 	for t.Kind() == reflect.Ptr {
 		t = t.Elem()
@@ -45,11 +54,12 @@ func Extract(s interface{}, tagNamespace string) (*Info, error) {
 	for i := 0; i < numFields; i++ {
 		f := t.Field(i)
 		fInfo := FieldInfo{
-			Index:    i,
-			Name:     f.Name,
-			Kind:     f.Type.Kind(),
-			Exported: isExported(f),
-			Tag:      f.Tag.Get(tagNamespace),
+			Index:     i,
+			Name:      f.Name,
+			Kind:      f.Type.Kind(),
+			Exported:  isExported(f),
+			Anonymous: f.Anonymous,
+			Tag:       f.Tag.Get(tagNamespace),
 		}
 		info.Fields.All = append(info.Fields.All, fInfo)
 		if fInfo.Exported {
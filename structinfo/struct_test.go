@@ -14,6 +14,15 @@ type user struct {
 	unexported int    `struct:"303"`
 }
 
+type base struct {
+	ID int `json:"id"`
+}
+
+type account struct {
+	base
+	Name string `json:"name"`
+}
+
 var extractTests = []struct {
 	in  interface{}
 	tag string
@@ -73,6 +82,26 @@ var extractTests = []struct {
 			},
 		},
 	},
+	{
+		in:  account{},
+		tag: "json",
+		err: nil,
+		out: &structinfo.Info{
+			NumFields: 2,
+			Fields: struct {
+				Exported []structinfo.FieldInfo
+				All      []structinfo.FieldInfo
+			}{
+				Exported: []structinfo.FieldInfo{
+					structinfo.FieldInfo{Index: 1, Name: "Name", Kind: reflect.String, Exported: true, Tag: "name"},
+				},
+				All: []structinfo.FieldInfo{
+					structinfo.FieldInfo{Index: 0, Name: "base", Kind: reflect.Struct, Exported: false, Anonymous: true, Tag: ""},
+					structinfo.FieldInfo{Index: 1, Name: "Name", Kind: reflect.String, Exported: true, Tag: "name"},
+				},
+			},
+		},
+	},
 	{
 		in:  new(int),
 		tag: "json",
@@ -92,3 +121,18 @@ func TestExtract(t *testing.T) {
 		}
 	}
 }
+
+func TestExtractType(t *testing.T) {
+	for i, test := range extractTests {
+		info, err := structinfo.ExtractType(reflect.TypeOf(test.in), test.tag)
+		if !reflect.DeepEqual(info, test.out) {
+			t.Errorf("#%d: mismatch\nexpecting:\t%#+v\ngot:\t\t%#+v", i, test.out, info)
+		}
+		if err != test.err {
+			t.Errorf("#%d: error mismatch\nexpecting: %+v\ngot: %+v", i, test.err, err)
+		}
+	}
+	if _, err := structinfo.ExtractType(nil, "json"); err != structinfo.ErrInvalidType {
+		t.Errorf("expecting invalid type error for nil type, got: %+v", err)
+	}
+}
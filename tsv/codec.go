@@ -0,0 +1,46 @@
+package tsv
+
+import (
+	"reflect"
+)
+
+// ValueOptions configures the conversions of a ValueCodec, as the tag options
+// of the same name do for struct fields
+type ValueOptions struct {
+	Layout     string      // Time layout. If empty, DefaultTimeLayout is used
+	Sep        string      // Slice separator. If empty, DefaultSliceSep is used
+	Conv       string      // Name of a registered converter
+	Converters *Converters // If nil, DefaultConverters is used
+}
+
+// ValueCodec converts single values to and from strings with the rules used
+// by Reader.Decode and Writer.Encode, so packages handling other text formats
+// can share them. It's safe for concurrent use
+type ValueCodec struct {
+	opts valueOpts
+}
+
+// NewValueCodec returns a ValueCodec with the given options. Type converters
+// registered afterwards are not used by it
+func NewValueCodec(opts ValueOptions) (*ValueCodec, error) {
+	convs := opts.Converters
+	if convs == nil {
+		convs = DefaultConverters
+	}
+	tag := fieldTag{opts: map[string]string{"layout": opts.Layout, "sep": opts.Sep, "conv": opts.Conv}}
+	vo, err := newValueOpts(tag, "", convs, convs.types())
+	if err != nil {
+		return nil, err
+	}
+	return &ValueCodec{opts: vo}, nil
+}
+
+// Decode converts val and sets it into v, which must be settable
+func (c *ValueCodec) Decode(val string, v reflect.Value) error {
+	return setVal(val, v, c.opts)
+}
+
+// Encode returns the string representation of v
+func (c *ValueCodec) Encode(v reflect.Value) (string, error) {
+	return formatVal(v, c.opts)
+}
@@ -0,0 +1,236 @@
+package tsv
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// ErrHeaderMismatch is returned when a part of a multi-part input has a header different from the first one
+var ErrHeaderMismatch = errors.New("header does not match the first part")
+
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Decompress detects gzip, bzip2 and zstd data by its magic bytes, returning
+// a reader of the decompressed data, or of the data as is if it isn't
+// compressed. Closing it releases the decompressor, but doesn't close r
+func Decompress(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzip.NewReader(br)
+	case bytes.HasPrefix(magic, bzip2Magic) && len(magic) > 3 && magic[3] >= '1' && magic[3] <= '9':
+		return io.NopCloser(bzip2.NewReader(br)), nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		dec, err := zstd.NewReader(br, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	}
+	return io.NopCloser(br), nil
+}
+
+// NewCompressedReader returns a Reader that reads from r using the given
+// dialect, decompressing the data if needed. It must be closed once done
+func NewCompressedReader(r io.Reader, d Dialect) (*Reader, error) {
+	rc, err := Decompress(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{r: closingReader{newRecordReader(rc, d), rc}}, nil
+}
+
+// Part is a named input of a multi-part reader
+type Part struct {
+	Name string
+	R    io.Reader
+}
+
+// NewMultiReader returns a Reader that reads the parts one after the other
+// using the given dialect, decompressing them if needed. Every part starts
+// with a header: the one of the first part is read as usual by ReadHeader,
+// and the others are checked against it and skipped. A part with a
+// different header fails with a *ParseError wrapping ErrHeaderMismatch, and
+// its rows are skipped. The part a row comes from is reported by File and
+// in the errors. The Reader must be closed once done
+func NewMultiReader(d Dialect, parts ...Part) *Reader {
+	m := &multiReader{d: d, names: make([]string, len(parts))}
+	for i, p := range parts {
+		m.names[i] = p.Name
+	}
+	m.open = func(i int) (io.ReadCloser, error) {
+		return Decompress(parts[i].R)
+	}
+	return &Reader{r: m}
+}
+
+// OpenFiles works like NewMultiReader, reading the named files. Each file
+// is opened when the previous one is exhausted and closed when read
+func OpenFiles(d Dialect, names ...string) *Reader {
+	m := &multiReader{d: d, names: names}
+	m.open = func(i int) (io.ReadCloser, error) {
+		f, err := os.Open(names[i])
+		if err != nil {
+			return nil, err
+		}
+		rc, err := Decompress(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return fileReader{rc, f}, nil
+	}
+	return &Reader{r: m}
+}
+
+// Close releases the decompressors and closes the files opened by the
+// Reader. Readers that don't open or decompress anything need not be closed
+func (r *Reader) Close() error {
+	if c, ok := r.r.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// File returns the name of the part the current row comes from, for multi-part readers
+func (r *Reader) File() string {
+	return r.rec.file
+}
+
+// closingReader is a recordReader that releases its input when closed
+type closingReader struct {
+	recordReader
+	c io.Closer
+}
+
+func (c closingReader) Close() error {
+	return c.c.Close()
+}
+
+// fileReader reads the decompressed data of a file, closing both when done
+type fileReader struct {
+	io.ReadCloser
+	f *os.File
+}
+
+func (f fileReader) Close() error {
+	err := f.ReadCloser.Close()
+	if ferr := f.f.Close(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// multiReader reads the records of several parts, checking their headers
+type multiReader struct {
+	d      Dialect
+	names  []string
+	open   func(i int) (io.ReadCloser, error)
+	i      int // index of the current part
+	cur    recordReader
+	in     io.ReadCloser
+	header []string
+}
+
+func (m *multiReader) Read(rec *record) error {
+	for {
+		if m.cur == nil {
+			if m.i >= len(m.names) {
+				return io.EOF
+			}
+			in, err := m.open(m.i)
+			if err != nil {
+				m.i++
+				return err
+			}
+			m.in = in
+			m.cur = newRecordReader(in, m.d)
+			if m.header != nil {
+				if err := m.checkHeader(rec); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		err := m.cur.Read(rec)
+		if err == io.EOF {
+			if err := m.next(); err != nil {
+				return err
+			}
+			continue
+		}
+		rec.file = m.names[m.i]
+		if perr, ok := err.(*ParseError); ok {
+			perr.File = rec.file
+		}
+		if err == nil && m.header == nil {
+			m.header = append([]string{}, rec.fields...)
+		}
+		return err
+	}
+}
+
+// checkHeader reads the header of the current part, which must match the
+// one of the first part. A part with a different header is skipped
+func (m *multiReader) checkHeader(rec *record) error {
+	err := m.cur.Read(rec)
+	if err == io.EOF {
+		return m.next()
+	}
+	if err == nil && !equalHeader(rec.fields, m.header) {
+		err = &ParseError{Line: rec.line, Column: -1, Err: ErrHeaderMismatch}
+	}
+	if err != nil {
+		if perr, ok := err.(*ParseError); ok {
+			perr.File = m.names[m.i]
+		}
+		m.next()
+	}
+	return err
+}
+
+// next closes the current part and moves on to the next one
+func (m *multiReader) next() error {
+	err := m.in.Close()
+	m.cur, m.in = nil, nil
+	m.i++
+	return err
+}
+
+func (m *multiReader) Close() error {
+	if m.in == nil {
+		return nil
+	}
+	err := m.in.Close()
+	m.cur, m.in = nil, nil
+	m.i = len(m.names)
+	return err
+}
+
+func equalHeader(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
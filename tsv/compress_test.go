@@ -0,0 +1,140 @@
+package tsv_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/syb-devs/gotools/tsv"
+)
+
+func gzipData(t *testing.T, s string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	io.WriteString(w, s)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zstdData(t *testing.T, s string) []byte {
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer enc.Close()
+	return enc.EncodeAll([]byte(s), nil)
+}
+
+func TestNewCompressedReader(t *testing.T) {
+	bz2, err := os.ReadFile("testdata/users.tsv.bz2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs := map[string][]byte{
+		"plain": []byte(users),
+		"gzip":  gzipData(t, users),
+		"bzip2": bz2,
+		"zstd":  zstdData(t, users),
+	}
+	want := []user{
+		{Name: "john doe", Age: 35, Range: -10, Active: true},
+		{Name: "jane doe", Age: 29, Range: 5},
+		{Name: "jim doe", Age: 40, Range: 3, Active: true},
+	}
+
+	for name, in := range inputs {
+		r, err := tsv.NewCompressedReader(bytes.NewReader(in), tsv.DefaultDialect)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		r.ReadHeader()
+		have, err := tsv.ReadAll[user](r)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+		if !reflect.DeepEqual(have, want) {
+			t.Errorf("%s: mismatch\nhave %#+v\nwant %#+v", name, have, want)
+		}
+		if err := r.Close(); err != nil {
+			t.Errorf("%s: unexpected error closing: %v", name, err)
+		}
+	}
+}
+
+func TestMultiReader(t *testing.T) {
+	r := tsv.NewMultiReader(tsv.DefaultDialect,
+		tsv.Part{Name: "a.tsv", R: strings.NewReader("name\tage\njohn\t35\njane\t28\n")},
+		tsv.Part{Name: "empty.tsv", R: strings.NewReader("")},
+		tsv.Part{Name: "b.tsv.gz", R: bytes.NewReader(gzipData(t, "name\tage\njim\t40\n"))},
+		tsv.Part{Name: "c.tsv", R: strings.NewReader("name\tyears\njoe\t25\n")},
+		tsv.Part{Name: "d.tsv", R: strings.NewReader("name\tage\njoe\t25\n")},
+	)
+	r.Lenient = true
+	defer r.Close()
+
+	if _, err := r.ReadHeader(); err != nil {
+		t.Fatalf("unexpected error reading header: %v", err)
+	}
+	var have []string
+	for r.Next() {
+		rec, err := r.Record()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		have = append(have, r.File()+": "+rec.Get("name"))
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"a.tsv: john", "a.tsv: jane", "b.tsv.gz: jim", "d.tsv: joe"}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("rows mismatch\nhave %v\nwant %v", have, want)
+	}
+
+	errs := r.Errors()
+	if len(errs) != 1 || errs[0].File != "c.tsv" || !errors.Is(errs[0], tsv.ErrHeaderMismatch) {
+		t.Fatalf("expecting a header mismatch in c.tsv, got %v", errs)
+	}
+	if want := "c.tsv: line 1: header does not match the first part"; errs[0].Error() != want {
+		t.Errorf("error message mismatch\nhave %q\nwant %q", errs[0].Error(), want)
+	}
+}
+
+func TestOpenFiles(t *testing.T) {
+	dir := t.TempDir()
+	parts := map[string][]byte{
+		"part1.tsv.gz":  gzipData(t, "name\tage\njohn\t35\n"),
+		"part2.tsv.zst": zstdData(t, "name\tage\njane\tabc\n"),
+	}
+	var names []string
+	for _, name := range []string{"part1.tsv.gz", "part2.tsv.zst"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, parts[name], 0644); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, path)
+	}
+
+	r := tsv.OpenFiles(tsv.DefaultDialect, names...)
+	defer r.Close()
+	r.ReadHeader()
+	var perr *tsv.ParseError
+	_, err := tsv.ReadAll[user](r)
+	if !errors.As(err, &perr) || perr.File != names[1] || perr.Line != 2 {
+		t.Errorf("expecting a parse error at line 2 of %s, got %v", names[1], err)
+	}
+
+	r = tsv.OpenFiles(tsv.DefaultDialect, filepath.Join(dir, "missing.tsv"))
+	if _, err := r.ReadHeader(); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expecting a not exist error, got %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package tsv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownConverter is returned when a conv tag option names a converter that is not registered
+var ErrUnknownConverter = errors.New("unknown converter")
+
+// Unmarshaler is implemented by types that can decode themselves from a TSV cell.
+// It takes precedence over encoding.TextUnmarshaler
+type Unmarshaler interface {
+	UnmarshalTSV(value string) error
+}
+
+// Marshaler is implemented by types that can encode themselves into a TSV cell.
+// It takes precedence over encoding.TextMarshaler
+type Marshaler interface {
+	MarshalTSV() (string, error)
+}
+
+// Converter converts TSV cells to and from values of a Go type
+type Converter struct {
+	// Decode converts a cell into a value assignable or convertible to the field type
+	Decode func(value string) (interface{}, error)
+
+	// Encode converts a field value into a cell. If nil, the value is formatted as usual
+	Encode func(v interface{}) (string, error)
+}
+
+// Converters is a set of converters, registered by name for the conv tag
+// option, or by the Go type they apply to. It's safe for concurrent use
+type Converters struct {
+	mu     sync.RWMutex
+	byName map[string]Converter
+	byType map[reflect.Type]Converter
+}
+
+// DefaultConverters is used by readers and writers that don't set their own
+// converters. It includes the eurodecimal and yesno converters
+var DefaultConverters = NewConverters()
+
+func init() {
+	DefaultConverters.Register("eurodecimal", Converter{Decode: decodeEuroDecimal, Encode: encodeEuroDecimal})
+	DefaultConverters.Register("yesno", Converter{Decode: decodeYesNo, Encode: encodeYesNo})
+}
+
+// NewConverters returns an empty set of converters
+func NewConverters() *Converters {
+	return &Converters{
+		byName: make(map[string]Converter),
+		byType: make(map[reflect.Type]Converter),
+	}
+}
+
+// Register registers a converter to be used by fields with the conv:name tag option
+func (c *Converters) Register(name string, conv Converter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.byName[name] = conv
+}
+
+// RegisterType registers a converter for every field or slice element with
+// the type of v. Pointers are dereferenced, so v can be a nil pointer
+func (c *Converters) RegisterType(v interface{}, conv Converter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.byType[indirectType(reflect.TypeOf(v))] = conv
+}
+
+// RegisterConverter registers a named converter in DefaultConverters
+func RegisterConverter(name string, conv Converter) {
+	DefaultConverters.Register(name, conv)
+}
+
+// RegisterTypeConverter registers a type converter in DefaultConverters
+func RegisterTypeConverter(v interface{}, conv Converter) {
+	DefaultConverters.RegisterType(v, conv)
+}
+
+func (c *Converters) named(name string) (*Converter, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	conv, ok := c.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownConverter, name)
+	}
+	return &conv, nil
+}
+
+// types returns a copy of the type converters, which can be used without locking
+func (c *Converters) types() map[reflect.Type]*Converter {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.byType) == 0 {
+		return nil
+	}
+	types := make(map[reflect.Type]*Converter, len(c.byType))
+	for t, conv := range c.byType {
+		conv := conv
+		types[t] = &conv
+	}
+	return types
+}
+
+// setConverted decodes val with the converter and sets the result into field
+func setConverted(val string, field reflect.Value, conv *Converter) error {
+	v, err := conv.Decode(val)
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(v)
+	switch {
+	case !rv.IsValid():
+		field.Set(reflect.Zero(field.Type()))
+	case rv.Type().AssignableTo(field.Type()):
+		field.Set(rv)
+	case convertible(rv.Type(), field.Type()):
+		field.Set(rv.Convert(field.Type()))
+	default:
+		return fmt.Errorf("converter returned %s for a field of type %s", rv.Type(), field.Type())
+	}
+	return nil
+}
+
+// convertible tells whether converter results of type from can be converted
+// to fields of type to without losing their meaning: floats are not
+// truncated into integers, and numbers don't become strings
+func convertible(from, to reflect.Type) bool {
+	if !from.ConvertibleTo(to) {
+		return false
+	}
+	fc, tc := kindClass(from.Kind()), kindClass(to.Kind())
+	return fc == tc || (fc == reflect.Int && tc == reflect.Float64)
+}
+
+// kindClass groups the integer kinds as Int and the float ones as Float64
+func kindClass(k reflect.Kind) reflect.Kind {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return reflect.Int
+	case reflect.Float32, reflect.Float64:
+		return reflect.Float64
+	}
+	return k
+}
+
+// decodeEuroDecimal parses numbers with dots as thousands separators and a decimal comma, like 1.234,56
+func decodeEuroDecimal(value string) (interface{}, error) {
+	value = strings.Replace(value, ".", "", -1)
+	value = strings.Replace(value, ",", ".", 1)
+	return strconv.ParseFloat(value, 64)
+}
+
+func encodeEuroDecimal(v interface{}) (string, error) {
+	f := reflect.ValueOf(v)
+	if f.Kind() != reflect.Float32 && f.Kind() != reflect.Float64 {
+		return "", ErrUnsuportedFieldType
+	}
+	s := strconv.FormatFloat(f.Float(), 'f', -1, f.Type().Bits())
+	return strings.Replace(s, ".", ",", 1), nil
+}
+
+// decodeYesNo parses Y/N and yes/no booleans, regardless of case
+func decodeYesNo(value string) (interface{}, error) {
+	switch strings.ToLower(value) {
+	case "y", "yes":
+		return true, nil
+	case "n", "no":
+		return false, nil
+	}
+	return nil, fmt.Errorf("invalid yes/no value %q", value)
+}
+
+func encodeYesNo(v interface{}) (string, error) {
+	b := reflect.ValueOf(v)
+	if b.Kind() != reflect.Bool {
+		return "", ErrUnsuportedFieldType
+	}
+	if b.Bool() {
+		return "Y", nil
+	}
+	return "N", nil
+}
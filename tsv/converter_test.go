@@ -0,0 +1,176 @@
+package tsv_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/syb-devs/gotools/tsv"
+)
+
+type cents int64
+
+type sku string
+
+func (s *sku) UnmarshalTSV(value string) error {
+	*s = sku(strings.ToUpper(value))
+	return nil
+}
+
+func (s sku) MarshalTSV() (string, error) {
+	return strings.ToLower(string(s)), nil
+}
+
+// UnmarshalText must not be used, as UnmarshalTSV takes precedence
+func (s *sku) UnmarshalText(text []byte) error {
+	return errors.New("UnmarshalText should not be called")
+}
+
+type order struct {
+	SKU     sku       `tsv:"sku"`
+	Price   float64   `tsv:"price,conv=eurodecimal"`
+	Prices  []float32 `tsv:"prices,conv=eurodecimal,sep=;"`
+	Paid    bool      `tsv:"paid,conv=yesno"`
+	Gift    *bool     `tsv:"gift,conv=yesno"`
+	Shipped time.Time `tsv:"shipped,layout=02/01/2006"`
+	Total   cents     `tsv:"total"`
+}
+
+func newConverters() *tsv.Converters {
+	convs := tsv.NewConverters()
+	convs.Register("eurodecimal", tsv.Converter{Decode: func(value string) (interface{}, error) {
+		return tsv.DefaultConverters, errors.New("the reader converters must be used")
+	}})
+	return convs
+}
+
+var centsConverter = tsv.Converter{
+	Decode: func(value string) (interface{}, error) {
+		value = strings.Replace(strings.Replace(value, ".", "", -1), ",", "", 1)
+		return strconv.ParseInt(value, 10, 64)
+	},
+	Encode: func(v interface{}) (string, error) {
+		c := v.(cents)
+		return fmt.Sprintf("%d,%02d", c/100, c%100), nil
+	},
+}
+
+func init() {
+	tsv.RegisterTypeConverter(cents(0), centsConverter)
+}
+
+const orders = "sku\tprice\tprices\tpaid\tgift\tshipped\ttotal\n" +
+	"ab-12\t1.234,56\t1,5;2\tY\tn\t31/12/2015\t1.234,56\n"
+
+func TestDecodeConverters(t *testing.T) {
+	r := tsv.NewReader(strings.NewReader(orders))
+	r.ReadHeader()
+	r.Next()
+	have := &order{}
+	if err := r.Decode(have); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := &order{
+		SKU:     "AB-12",
+		Price:   1234.56,
+		Prices:  []float32{1.5, 2},
+		Paid:    true,
+		Gift:    new(bool),
+		Shipped: time.Date(2015, 12, 31, 0, 0, 0, 0, time.UTC),
+		Total:   123456,
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("mismatch\nhave %#+v\nwant %#+v", have, want)
+	}
+}
+
+func TestConvertersRoundTrip(t *testing.T) {
+	in := "sku\tprice\tprices\tpaid\tgift\tshipped\ttotal\n" +
+		"ab-12\t1234,56\t1,5;2\tY\tN\t31/12/2015\t1234,56\n"
+	r := tsv.NewReader(strings.NewReader(in))
+	r.ReadHeader()
+	r.Next()
+	o := &order{}
+	if err := r.Decode(o); err != nil {
+		t.Fatalf("unexpected error decoding: %v", err)
+	}
+
+	var buf bytes.Buffer
+	w := tsv.NewWriter(&buf)
+	if err := w.Encode(o); err != nil {
+		t.Fatalf("unexpected error encoding: %v", err)
+	}
+	w.Flush()
+	if buf.String() != in {
+		t.Errorf("mismatch\nhave %q\nwant %q", buf.String(), in)
+	}
+}
+
+func TestReaderConverters(t *testing.T) {
+	r := tsv.NewReader(strings.NewReader(orders))
+	r.Converters = newConverters()
+	r.ReadHeader()
+	r.Next()
+	if err := r.Decode(&order{}); !errors.Is(err, tsv.ErrUnknownConverter) {
+		t.Errorf("expecting unknown converter error for yesno, got %v", err)
+	}
+
+	r = tsv.NewReader(strings.NewReader(orders))
+	r.Converters = newConverters()
+	r.Converters.Register("yesno", tsv.Converter{})
+	r.ReadHeader()
+	r.Next()
+	var perr *tsv.ParseError
+	if err := r.Decode(&order{}); !errors.As(err, &perr) || perr.Field != "Price" {
+		t.Errorf("expecting the reader eurodecimal converter to fail, got %v", err)
+	}
+}
+
+func TestConverterKinds(t *testing.T) {
+	var qty struct {
+		Qty int `tsv:"qty,conv=eurodecimal"`
+	}
+	r := tsv.NewReader(strings.NewReader("qty\n1,75\n"))
+	r.ReadHeader()
+	r.Next()
+	var perr *tsv.ParseError
+	if err := r.Decode(&qty); !errors.As(err, &perr) || perr.Field != "Qty" {
+		t.Errorf("expecting a float not to be truncated into an int, got %v, %d", err, qty.Qty)
+	}
+
+	var total struct {
+		Total float64 `tsv:"total,conv=cents"`
+	}
+	convs := tsv.NewConverters()
+	convs.Register("cents", centsConverter)
+	r = tsv.NewReader(strings.NewReader("total\n1.234,56\n"))
+	r.Converters = convs
+	r.ReadHeader()
+	r.Next()
+	if err := r.Decode(&total); err != nil || total.Total != 123456 {
+		t.Errorf("expecting an int to be converted to a float, got %v, %v", err, total.Total)
+	}
+}
+
+func TestValueCodec(t *testing.T) {
+	codec, err := tsv.NewValueCodec(tsv.ValueOptions{Conv: "eurodecimal"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var f float64
+	if err := codec.Decode("1.234,5", reflect.ValueOf(&f).Elem()); err != nil || f != 1234.5 {
+		t.Errorf("expecting 1234.5, got %v, %v", f, err)
+	}
+	if s, err := codec.Encode(reflect.ValueOf(f)); err != nil || s != "1234,5" {
+		t.Errorf("expecting 1234,5, got %q, %v", s, err)
+	}
+
+	if _, err := tsv.NewValueCodec(tsv.ValueOptions{Conv: "nope"}); !errors.Is(err, tsv.ErrUnknownConverter) {
+		t.Errorf("expecting an unknown converter error, got %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package tsv
+
+import (
+	"encoding"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTimeLayout is the layout used to parse and format time.Time fields
+	// without a layout tag option. Parsing accepts times without fractional seconds
+	DefaultTimeLayout = time.RFC3339Nano
+
+	// DefaultSliceSep is the separator used to split slice fields without a sep tag option
+	DefaultSliceSep = ","
+)
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	unmarshalerType     = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+)
+
+// valueOpts holds the options used to convert a TSV string into a field value
+type valueOpts struct {
+	layout string
+	sep    string
+	conv   *Converter
+	types  map[reflect.Type]*Converter
+}
+
+func newValueOpts(tag fieldTag, sliceSep string, convs *Converters, types map[reflect.Type]*Converter) (valueOpts, error) {
+	if sliceSep == "" {
+		sliceSep = DefaultSliceSep
+	}
+	opts := valueOpts{
+		layout: tag.opt("layout", DefaultTimeLayout),
+		sep:    tag.opt("sep", sliceSep),
+		types:  types,
+	}
+	if name := tag.opt("conv", ""); name != "" {
+		conv, err := convs.named(name)
+		if err != nil {
+			return opts, err
+		}
+		opts.conv = conv
+	}
+	return opts, nil
+}
+
+// leafConv returns the converter that applies to the field: the one named in
+// the tag, which applies to slice elements rather than whole slices, or the
+// one registered for the field type
+func (opts valueOpts) leafConv(field reflect.Value) *Converter {
+	if opts.conv != nil && (field.Kind() != reflect.Slice || field.Type().Elem().Kind() == reflect.Uint8) {
+		return opts.conv
+	}
+	return opts.types[field.Type()]
+}
+
+func setVal(val string, field reflect.Value, opts valueOpts) error {
+	if field.Kind() == reflect.Ptr {
+		if val == "" {
+			// nil pointers are encoded as empty cells
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		}
+		v := reflect.New(field.Type().Elem())
+		if err := setVal(val, v.Elem(), opts); err != nil {
+			return err
+		}
+		field.Set(v)
+		return nil
+	}
+	if conv := opts.leafConv(field); conv != nil && conv.Decode != nil {
+		return setConverted(val, field, conv)
+	}
+	if field.CanAddr() && field.Addr().Type().Implements(unmarshalerType) {
+		return field.Addr().Interface().(Unmarshaler).UnmarshalTSV(val)
+	}
+	if field.Type() == timeType {
+		t, err := time.Parse(opts.layout, val)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if field.CanAddr() && field.Addr().Type().Implements(textUnmarshalerType) {
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(val))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(val)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		intVal, err := strconv.ParseInt(val, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(intVal)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		uintVal, err := strconv.ParseUint(val, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(uintVal)
+	case reflect.Float32, reflect.Float64:
+		floatVal, err := strconv.ParseFloat(val, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(floatVal)
+	case reflect.Bool:
+		boolVal, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		field.SetBool(boolVal)
+	case reflect.Slice:
+		return setSlice(val, field, opts)
+	default:
+		return ErrUnsuportedFieldType
+	}
+	return nil
+}
+
+func setSlice(val string, field reflect.Value, opts valueOpts) error {
+	if field.Type().Elem().Kind() == reflect.Uint8 {
+		field.SetBytes([]byte(val))
+		return nil
+	}
+	if val == "" {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	items := strings.Split(val, opts.sep)
+	s := reflect.MakeSlice(field.Type(), len(items), len(items))
+	for i, item := range items {
+		if err := setVal(item, s.Index(i), opts); err != nil {
+			return err
+		}
+	}
+	field.Set(s)
+	return nil
+}
@@ -0,0 +1,308 @@
+package tsv
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Dialect describes the format of delimiter separated text
+type Dialect struct {
+	// Delimiter is the field delimiter
+	Delimiter rune
+
+	// Quote is the character used to quote fields. Quoted fields may contain
+	// delimiters and line breaks, and a quote is written as two quotes.
+	// Zero disables quoting
+	Quote rune
+
+	// Escape enables backslash escapes in unquoted fields: \t, \n, \r, \\ and
+	// \0 stand for tab, line feed, carriage return, backslash and NUL, and a
+	// backslash before any other character stands for that character
+	Escape bool
+
+	// Comment, if not zero, is the character that starts comment lines, which are skipped
+	Comment rune
+
+	// Null, if not empty, is the raw value that marks a cell as NULL, like \N.
+	// NULL cells are read as empty, and IsNull tells them apart
+	Null string
+
+	// TrimSpace removes leading and trailing white space from unquoted fields
+	TrimSpace bool
+}
+
+var (
+	// DefaultDialect is the dialect used by NewReader: tab delimited with CSV style quoting
+	DefaultDialect = Dialect{Delimiter: '\t', Quote: '"'}
+
+	// IANADialect follows the IANA text/tab-separated-values format, with no quoting at all
+	IANADialect = Dialect{Delimiter: '\t'}
+
+	// MySQLDialect reads the output of MySQL's SELECT ... INTO OUTFILE and mysqldump --tab
+	MySQLDialect = Dialect{Delimiter: '\t', Escape: true, Null: `\N`}
+
+	// CSVDialect reads comma separated values as described in RFC 4180
+	CSVDialect = Dialect{Delimiter: ',', Quote: '"'}
+
+	// PipeDialect reads pipe separated values with no quoting
+	PipeDialect = Dialect{Delimiter: '|'}
+)
+
+var dialects = map[string]Dialect{
+	"tsv":   DefaultDialect,
+	"iana":  IANADialect,
+	"mysql": MySQLDialect,
+	"csv":   CSVDialect,
+	"pipe":  PipeDialect,
+}
+
+// DialectByName returns the predefined dialect with the given name: tsv,
+// iana, mysql, csv or pipe
+func DialectByName(name string) (Dialect, bool) {
+	d, ok := dialects[name]
+	return d, ok
+}
+
+// record is a row as returned by a recordReader
+type record struct {
+	fields []string
+	nulls  []bool
+	line   int
+	end    int    // line where the record ends
+	offset int64  // input offset after the record
+	file   string // part of a multi-part input
+}
+
+// recordReader splits the input into records
+type recordReader interface {
+	Read(rec *record) error
+}
+
+func newRecordReader(r io.Reader, d Dialect) recordReader {
+	if d.Quote == '"' && !d.Escape && !d.TrimSpace {
+		cr := csv.NewReader(r)
+		cr.Comma = d.Delimiter
+		cr.Comment = d.Comment
+		cr.FieldsPerRecord = -1
+		return &csvReader{r: cr, null: d.Null}
+	}
+	return &textReader{r: bufio.NewReader(r), d: d}
+}
+
+// csvReader reads records using encoding/csv, which handles the default dialect
+type csvReader struct {
+	r    *csv.Reader
+	null string
+}
+
+func (c *csvReader) Read(rec *record) error {
+	fields, err := c.r.Read()
+	if err != nil {
+		if csvErr, ok := err.(*csv.ParseError); ok {
+			return &ParseError{Line: csvErr.Line, Column: -1, Err: csvErr.Err}
+		}
+		return err
+	}
+	rec.fields = fields
+	rec.line, _ = c.r.FieldPos(0)
+	// FieldPos tells where the last field starts, and a quoted one may span lines
+	last := fields[len(fields)-1]
+	rec.end, _ = c.r.FieldPos(len(fields) - 1)
+	rec.end += strings.Count(last, "\n")
+	rec.offset = c.r.InputOffset()
+	rec.nulls = nil
+	if c.null != "" {
+		rec.nulls = make([]bool, len(fields))
+		for i, f := range fields {
+			if rec.nulls[i] = f == c.null; rec.nulls[i] {
+				fields[i] = ""
+			}
+		}
+	}
+	return nil
+}
+
+// textReader reads records of any dialect
+type textReader struct {
+	r      *bufio.Reader
+	d      Dialect
+	line   int
+	offset int64
+	last   rune // last rune read
+	size   int  // size of the last rune read
+}
+
+func (t *textReader) Read(rec *record) error {
+	for {
+		if crlf, _ := t.r.Peek(2); string(crlf) == "\r\n" {
+			// skip the CR of empty CRLF lines, leaving the LF
+			t.readRune()
+		}
+		r, err := t.readRune()
+		if err != nil {
+			return err
+		}
+		if r == '\n' {
+			// empty lines are skipped, as encoding/csv does
+			continue
+		}
+		if t.d.Comment != 0 && r == t.d.Comment {
+			if err := t.skipLine(); err != nil {
+				return err
+			}
+			continue
+		}
+		t.r.UnreadRune()
+		t.offset -= int64(t.size)
+		break
+	}
+
+	rec.fields = nil
+	rec.nulls = nil
+	rec.line = t.line + 1
+	for {
+		field, null, last, err := t.readField()
+		if err == csv.ErrQuote {
+			// discard the rest of the line so the next record can be read
+			t.skipLine()
+			return &ParseError{Line: rec.line, Column: -1, Err: err}
+		}
+		if err != nil {
+			return err
+		}
+		if null {
+			if rec.nulls == nil {
+				rec.nulls = make([]bool, len(rec.fields), len(rec.fields)+1)
+			}
+		}
+		if rec.nulls != nil {
+			rec.nulls = append(rec.nulls, null)
+		}
+		rec.fields = append(rec.fields, field)
+		if last {
+			rec.end, rec.offset = t.line, t.offset
+			if t.last != '\n' {
+				rec.end++
+			}
+			return nil
+		}
+	}
+}
+
+// readField reads one field, telling if it's NULL and the last one of the record
+func (t *textReader) readField() (field string, null, last bool, err error) {
+	var val, raw strings.Builder
+	r, err := t.readRune()
+	for t.d.TrimSpace && err == nil && r != t.d.Delimiter && r != '\n' && unicode.IsSpace(r) {
+		r, err = t.readRune()
+	}
+	if err == nil && t.d.Quote != 0 && r == t.d.Quote {
+		return t.readQuoted()
+	}
+	for ; err == nil; r, err = t.readRune() {
+		if r == t.d.Delimiter || r == '\n' {
+			break
+		}
+		raw.WriteRune(r)
+		if t.d.Escape && r == '\\' {
+			if r, err = t.readRune(); err != nil {
+				val.WriteRune('\\')
+				break
+			}
+			raw.WriteRune(r)
+			val.WriteRune(unescape(r))
+			continue
+		}
+		val.WriteRune(r)
+	}
+	if err != nil && err != io.EOF {
+		return "", false, false, err
+	}
+
+	field = strings.TrimSuffix(val.String(), "\r")
+	if t.d.TrimSpace {
+		field = strings.TrimSpace(field)
+	}
+	if null = t.d.Null != "" && strings.TrimSuffix(raw.String(), "\r") == t.d.Null; null {
+		field = ""
+	}
+	return field, null, r != t.d.Delimiter || err == io.EOF, nil
+}
+
+func (t *textReader) readQuoted() (field string, null, last bool, err error) {
+	var val strings.Builder
+	for {
+		r, err := t.readRune()
+		if err != nil {
+			if err == io.EOF {
+				err = csv.ErrQuote
+			}
+			return "", false, false, err
+		}
+		if r != t.d.Quote {
+			val.WriteRune(r)
+			continue
+		}
+		r, err = t.readRune()
+		for t.d.TrimSpace && err == nil && r != t.d.Delimiter && r != '\n' && unicode.IsSpace(r) {
+			r, err = t.readRune()
+		}
+		switch {
+		case err == io.EOF || r == '\n':
+			return val.String(), false, true, nil
+		case err != nil:
+			return "", false, false, err
+		case r == t.d.Quote:
+			val.WriteRune(r)
+		case r == t.d.Delimiter:
+			return val.String(), false, false, nil
+		case r == '\r':
+			if r, err = t.readRune(); err == io.EOF || r == '\n' {
+				return val.String(), false, true, nil
+			}
+			return "", false, false, csv.ErrQuote
+		default:
+			return "", false, false, csv.ErrQuote
+		}
+	}
+}
+
+func (t *textReader) readRune() (rune, error) {
+	r, size, err := t.r.ReadRune()
+	if err != nil {
+		t.last = 0
+		return r, err
+	}
+	t.offset += int64(size)
+	t.last, t.size = r, size
+	if r == '\n' {
+		t.line++
+	}
+	return r, nil
+}
+
+func (t *textReader) skipLine() error {
+	for {
+		r, err := t.readRune()
+		if err != nil || r == '\n' {
+			return err
+		}
+	}
+}
+
+func unescape(r rune) rune {
+	switch r {
+	case 't':
+		return '\t'
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case '0':
+		return 0
+	}
+	return r
+}
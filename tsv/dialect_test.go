@@ -0,0 +1,146 @@
+package tsv_test
+
+import (
+	"encoding/csv"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/syb-devs/gotools/tsv"
+)
+
+var dialectTests = []struct {
+	dialect tsv.Dialect
+	in      string
+	out     [][]string
+	nulls   [][]int
+	lines   []int
+}{
+	{
+		dialect: tsv.DefaultDialect,
+		in:      "a\t\"b\tc\"\n\"multi\nline\"\td",
+		out:     [][]string{{"a", "b\tc"}, {"multi\nline", "d"}},
+		lines:   []int{1, 2},
+	},
+	{
+		dialect: tsv.IANADialect,
+		in:      "a\t\"b\"\n\nc\t\"d\r\n",
+		out:     [][]string{{"a", `"b"`}, {"c", `"d`}},
+		lines:   []int{1, 3},
+	},
+	{
+		dialect: tsv.MySQLDialect,
+		in:      "1\ttab\\there\t\\N\n2\tline\\nbreak\\\\\tN\n",
+		out:     [][]string{{"1", "tab\there", ""}, {"2", "line\nbreak\\", "N"}},
+		nulls:   [][]int{{2}, nil},
+		lines:   []int{1, 2},
+	},
+	{
+		dialect: tsv.CSVDialect,
+		in:      "# not a comment,x\na,\"b,\"\"c\"\"\"\n",
+		out:     [][]string{{"# not a comment", "x"}, {"a", `b,"c"`}},
+		lines:   []int{1, 2},
+	},
+	{
+		dialect: tsv.Dialect{Delimiter: ',', Quote: '\'', Comment: '#', TrimSpace: true},
+		in:      "# comment\n a , 'b, c' \n# another\n'x''y',z",
+		out:     [][]string{{"a", "b, c"}, {"x'y", "z"}},
+		lines:   []int{2, 4},
+	},
+	{
+		dialect: tsv.PipeDialect,
+		in:      "a|b|c\r\nd||f",
+		out:     [][]string{{"a", "b", "c"}, {"d", "", "f"}},
+		lines:   []int{1, 2},
+	},
+	{
+		dialect: tsv.MySQLDialect,
+		in:      "a\tb\r\n\r\nc\t\\N\r\n",
+		out:     [][]string{{"a", "b"}, {"c", ""}},
+		nulls:   [][]int{nil, {1}},
+		lines:   []int{1, 3},
+	},
+	{
+		dialect: tsv.Dialect{Delimiter: '\t', Quote: '"', Null: `\N`},
+		in:      "a\tb\r\n\r\nc\t\\N\r\n",
+		out:     [][]string{{"a", "b"}, {"c", ""}},
+		nulls:   [][]int{nil, {1}},
+		lines:   []int{1, 3},
+	},
+}
+
+func TestDialects(t *testing.T) {
+	for i, test := range dialectTests {
+		r := tsv.NewDialectReader(strings.NewReader(test.in), test.dialect)
+		var rows [][]string
+		var lines []int
+		var nulls [][]int
+		for {
+			row, err := r.Read()
+			if err != nil {
+				if r.Err() != nil {
+					t.Errorf("#%d: unexpected error: %v", i, err)
+				}
+				break
+			}
+			rows = append(rows, row)
+			lines = append(lines, r.Line())
+			var rowNulls []int
+			for col := range row {
+				if r.IsNull(col) {
+					rowNulls = append(rowNulls, col)
+				}
+			}
+			nulls = append(nulls, rowNulls)
+		}
+		if !reflect.DeepEqual(rows, test.out) {
+			t.Errorf("#%d: rows mismatch\nhave %q\nwant %q", i, rows, test.out)
+		}
+		if !reflect.DeepEqual(lines, test.lines) {
+			t.Errorf("#%d: lines mismatch\nhave %v\nwant %v", i, lines, test.lines)
+		}
+		if test.nulls != nil && !reflect.DeepEqual(nulls, test.nulls) {
+			t.Errorf("#%d: nulls mismatch\nhave %v\nwant %v", i, nulls, test.nulls)
+		}
+	}
+}
+
+func TestDialectByName(t *testing.T) {
+	if d, ok := tsv.DialectByName("mysql"); !ok || d != tsv.MySQLDialect {
+		t.Errorf("expecting the MySQL dialect, got %+v, %v", d, ok)
+	}
+	if _, ok := tsv.DialectByName("excel"); ok {
+		t.Error("expecting an unknown dialect")
+	}
+}
+
+func TestDialectBadQuote(t *testing.T) {
+	r := tsv.NewDialectReader(strings.NewReader("'a'b,c\nd,e"), tsv.Dialect{Delimiter: ',', Quote: '\''})
+	if _, err := r.Read(); !errors.Is(err, csv.ErrQuote) {
+		t.Errorf("expecting quote error, got %v", err)
+	}
+	row, err := r.Read()
+	if err != nil || !reflect.DeepEqual(row, []string{"d", "e"}) {
+		t.Errorf("expecting reader to recover after a quote error, got %q, %v", row, err)
+	}
+}
+
+type nullable struct {
+	ID    int     `tsv:"id"`
+	Name  *string `tsv:"name"`
+	Score *int    `tsv:"score"`
+}
+
+func TestDecodeNull(t *testing.T) {
+	r := tsv.NewDialectReader(strings.NewReader("id\tname\tscore\n7\t\\N\t\\N\n"), tsv.MySQLDialect)
+	r.ReadHeader()
+	r.Next()
+	have := &nullable{}
+	if err := r.Decode(have); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (&nullable{ID: 7}); !reflect.DeepEqual(have, want) {
+		t.Errorf("mismatch\nhave %#+v\nwant %#+v", have, want)
+	}
+}
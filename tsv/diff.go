@@ -0,0 +1,354 @@
+package tsv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrNoKey is returned by Diff and Merge when no key columns are given
+	ErrNoKey = errors.New("no key columns")
+
+	// ErrUnsorted is returned by Diff for sorted inputs with rows out of order
+	ErrUnsorted = errors.New("rows are not sorted by key")
+
+	// ErrDuplicateKey is returned by Diff when an input has several rows with the same key
+	ErrDuplicateKey = errors.New("duplicate key")
+)
+
+// ChangeKind tells how a row changed
+type ChangeKind int
+
+const (
+	// Added rows are only in the new input
+	Added ChangeKind = iota + 1
+	// Removed rows are only in the old input
+	Removed
+	// Changed rows are in both inputs, with different values
+	Changed
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// CellChange is a cell that changed between two rows
+type CellChange struct {
+	Column string
+	Old    string
+	New    string
+}
+
+// Change is a row that was added, removed or changed
+type Change struct {
+	Kind    ChangeKind
+	Key     []string          // Values of the key columns
+	Old     map[string]string // Old row, nil for added rows
+	New     map[string]string // New row, nil for removed rows
+	Cells   []CellChange      // Changed cells, in column order
+	Columns []string          // Columns of both inputs, the old ones first
+}
+
+// DiffOptions configures Diff and Merge
+type DiffOptions struct {
+	// Key are the columns identifying a row
+	Key []string
+
+	// Sorted tells that both inputs are sorted by key, comparing the values of
+	// the key columns in order, as strings. Diff then streams both inputs
+	// with bounded memory. Otherwise the old input is held in memory
+	Sorted bool
+}
+
+// Diff compares the rows of two readers by key, calling fn with every row
+// added, removed or changed. Both headers are read by Diff, and the columns
+// are matched by name, taking cells of columns missing in one of the inputs
+// as empty. With sorted inputs the changes are reported in key order.
+// Otherwise, additions and changes come in the order of the new input,
+// followed by the removals in the order of the old one
+func Diff(old, new *Reader, opts DiffOptions, fn func(Change) error) error {
+	return diff(old, new, opts, false, fn)
+}
+
+// Merge writes to w the rows of two readers merged by key, with a header
+// made of the columns of both, the old ones first. Rows in both inputs take
+// the values of the new one, keeping the old cells of columns only in the old
+// input, and rows only in one of the inputs are written as they are. The
+// rows come in the order Diff reports them. w is flushed before returning
+func Merge(old, new *Reader, w *Writer, opts DiffOptions) error {
+	header := false
+	err := diff(old, new, opts, true, func(c Change) error {
+		if !header {
+			if err := w.Write(c.Columns); err != nil {
+				return err
+			}
+			header = true
+		}
+		row := make([]string, len(c.Columns))
+		for i, col := range c.Columns {
+			v, ok := c.New[col]
+			if !ok {
+				v = c.Old[col]
+			}
+			row[i] = v
+		}
+		return w.Write(row)
+	})
+	if err == nil && !header {
+		err = w.Write(unionColumns(old.header, new.header))
+	}
+	w.Flush()
+	if err != nil {
+		return err
+	}
+	return w.Error()
+}
+
+// diff compares the inputs, reporting also the unchanged rows, as changed
+// rows without cells, if all is set
+func diff(old, new *Reader, opts DiffOptions, all bool, fn func(Change) error) error {
+	if len(opts.Key) == 0 {
+		return ErrNoKey
+	}
+	oldSide, err := newDiffSide(old, opts.Key)
+	if err != nil {
+		return err
+	}
+	newSide, err := newDiffSide(new, opts.Key)
+	if err != nil {
+		return err
+	}
+	d := &differ{columns: unionColumns(old.header, new.header), all: all, fn: fn}
+	if opts.Sorted {
+		return d.merge(oldSide, newSide)
+	}
+	return d.hash(oldSide, newSide)
+}
+
+// diffSide is one of the inputs of a diff
+type diffSide struct {
+	r       *Reader
+	keyCols []int
+	row     []string // current row, nil at the end
+	key     string   // key of the current row, for sorted inputs
+	started bool
+}
+
+func newDiffSide(r *Reader, key []string) (*diffSide, error) {
+	header, err := r.ReadHeader()
+	if err != nil {
+		return nil, err
+	}
+	s := &diffSide{r: r, keyCols: make([]int, len(key))}
+	for i, col := range key {
+		if s.keyCols[i] = colIndex(header, col, false); s.keyCols[i] < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownColumn, col)
+		}
+	}
+	return s, nil
+}
+
+// next reads the next row, leaving row nil at the end of the input
+func (s *diffSide) next() error {
+	row, err := s.r.Read()
+	if err == io.EOF {
+		s.row = nil
+		return nil
+	}
+	s.row = row
+	return err
+}
+
+// keyOf returns the key of the row, with the values separated by NULs so
+// that keys compare as their values do, in order
+func (s *diffSide) keyOf(row []string) string {
+	return strings.Join(s.keyVals(row), "\x00")
+}
+
+func (s *diffSide) keyVals(row []string) []string {
+	vals := make([]string, len(s.keyCols))
+	for i, col := range s.keyCols {
+		vals[i] = row[col]
+	}
+	return vals
+}
+
+// nextSorted reads the next row, checking the input order
+func (s *diffSide) nextSorted() error {
+	if err := s.next(); err != nil || s.row == nil {
+		return err
+	}
+	key, prev, started := s.keyOf(s.row), s.key, s.started
+	s.key, s.started = key, true
+	switch {
+	case !started:
+		return nil
+	case key < prev:
+		return s.rowError(ErrUnsorted)
+	case key == prev:
+		return s.rowError(ErrDuplicateKey)
+	}
+	return nil
+}
+
+func (s *diffSide) rowError(err error) error {
+	return &ParseError{File: s.r.File(), Line: s.r.Line(), Column: -1, Err: err}
+}
+
+type differ struct {
+	columns []string
+	all     bool // report unchanged rows too
+	fn      func(Change) error
+}
+
+// merge walks both sorted inputs at once
+func (d *differ) merge(old, new *diffSide) error {
+	if err := old.nextSorted(); err != nil {
+		return err
+	}
+	if err := new.nextSorted(); err != nil {
+		return err
+	}
+	for old.row != nil || new.row != nil {
+		var err error
+		switch {
+		case new.row == nil || (old.row != nil && old.key < new.key):
+			err = d.report(Removed, old, old.row, nil)
+			if err == nil {
+				err = old.nextSorted()
+			}
+		case old.row == nil || new.key < old.key:
+			err = d.report(Added, new, nil, new.row)
+			if err == nil {
+				err = new.nextSorted()
+			}
+		default:
+			err = d.compare(old, new, old.row, new.row)
+			if err == nil {
+				err = old.nextSorted()
+			}
+			if err == nil {
+				err = new.nextSorted()
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hash holds the old input in memory and streams the new one
+func (d *differ) hash(old, new *diffSide) error {
+	rows := make(map[string][]string)
+	var keys []string
+	for {
+		if err := old.next(); err != nil {
+			return err
+		}
+		if old.row == nil {
+			break
+		}
+		key := old.keyOf(old.row)
+		if _, ok := rows[key]; ok {
+			return old.rowError(ErrDuplicateKey)
+		}
+		rows[key] = old.row
+		keys = append(keys, key)
+	}
+
+	seen := make(map[string]bool, len(rows))
+	for {
+		if err := new.next(); err != nil {
+			return err
+		}
+		if new.row == nil {
+			break
+		}
+		key := new.keyOf(new.row)
+		if seen[key] {
+			return new.rowError(ErrDuplicateKey)
+		}
+		seen[key] = true
+		oldRow, ok := rows[key]
+		var err error
+		if ok {
+			err = d.compare(old, new, oldRow, new.row)
+		} else {
+			err = d.report(Added, new, nil, new.row)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		if err := d.report(Removed, old, rows[key], nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compare reports the rows with the same key if any cell changed, or always with all set
+func (d *differ) compare(old, new *diffSide, oldRow, newRow []string) error {
+	oldVals := rowMap(old.r.header, oldRow)
+	newVals := rowMap(new.r.header, newRow)
+	var cells []CellChange
+	for _, col := range d.columns {
+		if oldVals[col] != newVals[col] {
+			cells = append(cells, CellChange{Column: col, Old: oldVals[col], New: newVals[col]})
+		}
+	}
+	if len(cells) == 0 && !d.all {
+		return nil
+	}
+	return d.fn(Change{Kind: Changed, Key: new.keyVals(newRow), Old: oldVals, New: newVals, Cells: cells, Columns: d.columns})
+}
+
+func (d *differ) report(kind ChangeKind, side *diffSide, oldRow, newRow []string) error {
+	c := Change{Kind: kind, Columns: d.columns}
+	if oldRow != nil {
+		c.Key = side.keyVals(oldRow)
+		c.Old = rowMap(side.r.header, oldRow)
+	}
+	if newRow != nil {
+		c.Key = side.keyVals(newRow)
+		c.New = rowMap(side.r.header, newRow)
+	}
+	return d.fn(c)
+}
+
+func rowMap(header, row []string) map[string]string {
+	m := make(map[string]string, len(header))
+	for i, col := range header {
+		if i < len(row) {
+			m[col] = row[i]
+		}
+	}
+	return m
+}
+
+// unionColumns returns the columns of a followed by those only in b
+func unionColumns(a, b []string) []string {
+	cols := append([]string{}, a...)
+	for _, col := range b {
+		if colIndex(a, col, false) < 0 {
+			cols = append(cols, col)
+		}
+	}
+	return cols
+}
@@ -0,0 +1,129 @@
+package tsv_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/syb-devs/gotools/tsv"
+)
+
+const (
+	oldStock = "sku\tstore\tprice\tstock\n" +
+		"a1\t1\t1.5\t10\n" +
+		"a1\t2\t1.5\t4\n" +
+		"b2\t1\t3\t7\n" +
+		"c3\t1\t9\t1\n"
+	newStock = "store\tsku\tprice\tstock\tcolor\n" +
+		"1\ta1\t1.5\t10\t\n" +
+		"2\ta1\t1.75\t3\t\n" +
+		"1\tb0\t2\t5\tred\n" +
+		"1\tc3\t9\t1\tblue\n"
+)
+
+func diffStock(old, new string, opts tsv.DiffOptions) ([]string, error) {
+	var changes []string
+	err := tsv.Diff(tsv.NewReader(strings.NewReader(old)), tsv.NewReader(strings.NewReader(new)), opts, func(c tsv.Change) error {
+		s := c.Kind.String() + " " + strings.Join(c.Key, "/")
+		for _, cell := range c.Cells {
+			s += " " + cell.Column + ":" + cell.Old + ">" + cell.New
+		}
+		changes = append(changes, s)
+		return nil
+	})
+	return changes, err
+}
+
+func TestDiff(t *testing.T) {
+	key := []string{"sku", "store"}
+	have, err := diffStock(oldStock, newStock, tsv.DiffOptions{Key: key, Sorted: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{
+		"changed a1/2 price:1.5>1.75 stock:4>3",
+		"added b0/1",
+		"removed b2/1",
+		"changed c3/1 color:>blue",
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("sorted mismatch\nhave %q\nwant %q", have, want)
+	}
+
+	have, err = diffStock(oldStock, newStock, tsv.DiffOptions{Key: key})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want = []string{
+		"changed a1/2 price:1.5>1.75 stock:4>3",
+		"added b0/1",
+		"changed c3/1 color:>blue",
+		"removed b2/1",
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("unsorted mismatch\nhave %q\nwant %q", have, want)
+	}
+}
+
+func TestDiffErrors(t *testing.T) {
+	unsorted := "sku\tstore\nb2\t1\na1\t1\n"
+	duplicated := "sku\tstore\na1\t1\na1\t1\n"
+	tests := []struct {
+		old, new string
+		opts     tsv.DiffOptions
+		err      error
+		line     int
+	}{
+		{oldStock, newStock, tsv.DiffOptions{}, tsv.ErrNoKey, 0},
+		{oldStock, newStock, tsv.DiffOptions{Key: []string{"id"}}, tsv.ErrUnknownColumn, 0},
+		{unsorted, "sku\nz9\n", tsv.DiffOptions{Key: []string{"sku"}, Sorted: true}, tsv.ErrUnsorted, 3},
+		{oldStock, duplicated, tsv.DiffOptions{Key: []string{"sku"}, Sorted: true}, tsv.ErrDuplicateKey, 3},
+		{duplicated, oldStock, tsv.DiffOptions{Key: []string{"sku", "store"}}, tsv.ErrDuplicateKey, 3},
+	}
+	for i, tt := range tests {
+		_, err := diffStock(tt.old, tt.new, tt.opts)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("#%d: error mismatch\nhave %v\nwant %v", i, err, tt.err)
+			continue
+		}
+		var perr *tsv.ParseError
+		if errors.As(err, &perr) && perr.Line != tt.line {
+			t.Errorf("#%d: expecting the error at line %d, got %d", i, tt.line, perr.Line)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	want := "sku\tstore\tprice\tstock\tcolor\n" +
+		"a1\t1\t1.5\t10\t\n" +
+		"a1\t2\t1.75\t3\t\n" +
+		"b0\t1\t2\t5\tred\n" +
+		"b2\t1\t3\t7\t\n" +
+		"c3\t1\t9\t1\tblue\n"
+	for _, sorted := range []bool{true, false} {
+		var buf strings.Builder
+		opts := tsv.DiffOptions{Key: []string{"sku", "store"}, Sorted: sorted}
+		err := tsv.Merge(tsv.NewReader(strings.NewReader(oldStock)), tsv.NewReader(strings.NewReader(newStock)), tsv.NewWriter(&buf), opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		have := buf.String()
+		if !sorted {
+			// the removed row comes last without sorting
+			want = strings.Replace(want, "b2\t1\t3\t7\t\n", "", 1) + "b2\t1\t3\t7\t\n"
+		}
+		if have != want {
+			t.Errorf("sorted %v: mismatch\nhave %q\nwant %q", sorted, have, want)
+		}
+	}
+
+	var buf strings.Builder
+	err := tsv.Merge(tsv.NewReader(strings.NewReader("id\tname\tnote\n1\tann\tx\n")), tsv.NewReader(strings.NewReader("id\tname\n1\tbob\n")), tsv.NewWriter(&buf), tsv.DiffOptions{Key: []string{"id"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "id\tname\tnote\n1\tbob\tx\n"; buf.String() != want {
+		t.Errorf("mismatch\nhave %q\nwant %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,36 @@
+/*
+Package tsv reads and writes tab separated values, and other delimiter
+separated dialects, mapping rows to structs.
+
+Struct fields are mapped to columns with the tsv tag. The first element of the
+tag is the column name, and it's followed by comma separated options in the
+form key:value or key=value:
+
+	Name    string    `tsv:"name"`                  // column named "name"
+	Active  bool      `tsv:",col:3"`                // fourth column
+	Born    time.Time `tsv:"born,layout:2006-01-02"` // time layout, RFC 3339 by default
+	Tags    []string  `tsv:"tags,sep:|"`            // slice separator, "," by default
+	Email   string    `tsv:"email,required,alias:mail|e-mail"`
+	Age     int       `tsv:"age,default=18"`        // value for empty cells
+	Count   int       `tsv:"count,omitempty"`       // zero values are written as empty cells
+	Price   float64   `tsv:"price,conv=eurodecimal"` // registered converter, see Converters
+	Address Address   `tsv:"address"`              // nested struct, columns "address.street" ...
+	Billing Address   `tsv:",prefix:bill_"`         // nested struct, columns "bill_street" ...
+	Ignored string    `tsv:"-"`
+
+Untagged fields are ignored, except embedded structs, whose fields are
+promoted as Go does.
+
+Field types implementing Unmarshaler and Marshaler, or their encoding.Text
+counterparts, convert themselves.
+
+Decoded values are checked against the rules of the validate tag of their
+field, described in the validate package, and the broken rules are reported
+as a *ParseError with the row and column:
+
+	Email string `tsv:"email" validate:"required,regex=^[^@]+@[^@]+$"`
+
+Gzip, bzip2 and zstd compressed input is detected by NewCompressedReader, and
+inputs split in several parts are read as one by NewMultiReader and OpenFiles.
+*/
+package tsv
@@ -0,0 +1,111 @@
+package tsv
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrSliceSep is returned when encoding a slice with an element containing
+// the separator, as it couldn't be decoded back
+var ErrSliceSep = errors.New("slice element contains the separator")
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	marshalerType     = reflect.TypeOf((*Marshaler)(nil)).Elem()
+)
+
+// formatVal returns the TSV string representation of the field value.
+// It's the inverse of setVal
+func formatVal(field reflect.Value, opts valueOpts) (string, error) {
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return "", nil
+		}
+		return formatVal(field.Elem(), opts)
+	}
+	if conv := opts.leafConv(field); conv != nil && conv.Encode != nil {
+		return conv.Encode(field.Interface())
+	}
+	if m, ok := marshaler(field); ok {
+		return m.MarshalTSV()
+	}
+	if field.Type() == timeType {
+		return field.Interface().(time.Time).Format(opts.layout), nil
+	}
+	if m, ok := textMarshaler(field); ok {
+		text, err := m.MarshalText()
+		return string(text), err
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		return field.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(field.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(field.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(field.Float(), 'f', -1, field.Type().Bits()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(field.Bool()), nil
+	case reflect.Slice:
+		return formatSlice(field, opts)
+	default:
+		return "", ErrUnsuportedFieldType
+	}
+}
+
+func formatSlice(field reflect.Value, opts valueOpts) (string, error) {
+	if field.Type().Elem().Kind() == reflect.Uint8 {
+		return string(field.Bytes()), nil
+	}
+	items := make([]string, field.Len())
+	for i := range items {
+		item, err := formatVal(field.Index(i), opts)
+		if err != nil {
+			return "", err
+		}
+		if opts.sep != "" && strings.Contains(item, opts.sep) {
+			return "", fmt.Errorf("%w %q: %q", ErrSliceSep, opts.sep, item)
+		}
+		items[i] = item
+	}
+	return strings.Join(items, opts.sep), nil
+}
+
+func textMarshaler(field reflect.Value) (encoding.TextMarshaler, bool) {
+	if field.Type().Implements(textMarshalerType) {
+		return field.Interface().(encoding.TextMarshaler), true
+	}
+	if reflect.PtrTo(field.Type()).Implements(textMarshalerType) {
+		return addr(field).Interface().(encoding.TextMarshaler), true
+	}
+	return nil, false
+}
+
+func marshaler(field reflect.Value) (Marshaler, bool) {
+	if field.Type().Implements(marshalerType) {
+		return field.Interface().(Marshaler), true
+	}
+	if reflect.PtrTo(field.Type()).Implements(marshalerType) {
+		return addr(field).Interface().(Marshaler), true
+	}
+	return nil, false
+}
+
+// addr returns a pointer to the field, or to a copy of it if it is not
+// addressable, as the fields of structs encoded by value, so that methods
+// with pointer receivers can be called
+func addr(field reflect.Value) reflect.Value {
+	if field.CanAddr() {
+		return field.Addr()
+	}
+	p := reflect.New(field.Type())
+	p.Elem().Set(field)
+	return p
+}
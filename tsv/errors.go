@@ -0,0 +1,78 @@
+package tsv
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseError is returned for errors found in a TSV row. Column is -1 when the
+// error affects the whole row, like a column count mismatch
+type ParseError struct {
+	File   string // Name of the part, for multi-part readers
+	Line   int    // Line where the row starts
+	Column int    // Column index, starting at 0
+	Name   string // Column name, taken from the header
+	Field  string // Name of the struct field being decoded
+	Value  string // Raw value of the cell
+	Err    error  // The actual error
+}
+
+func (e *ParseError) Error() string {
+	if e.File != "" {
+		return e.File + ": " + e.error()
+	}
+	return e.error()
+}
+
+func (e *ParseError) error() string {
+	if e.Column < 0 {
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+	col := fmt.Sprintf("%d", e.Column)
+	if e.Name != "" {
+		col = fmt.Sprintf("%d (%s)", e.Column, e.Name)
+	}
+	if e.Field != "" {
+		col += ", field " + e.Field
+	}
+	return fmt.Sprintf("line %d, column %s: invalid value %q: %v", e.Line, col, e.Value, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// HeaderError reports the differences between a header and the columns mapped by a struct
+type HeaderError struct {
+	Missing []string // Required columns not present in the header
+	Unknown []string // Header columns not mapped to any struct field
+}
+
+func (e *HeaderError) Error() string {
+	var msgs []string
+	if len(e.Missing) > 0 {
+		msgs = append(msgs, "missing required columns: "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Unknown) > 0 {
+		msgs = append(msgs, "unknown columns: "+strings.Join(e.Unknown, ", "))
+	}
+	return "invalid header: " + strings.Join(msgs, "; ")
+}
+
+// ErrorList is a list of row errors, as collected in lenient mode
+type ErrorList []*ParseError
+
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	}
+	msgs := make([]string, len(l))
+	for i, err := range l {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d errors: %s", len(l), strings.Join(msgs, "; "))
+}
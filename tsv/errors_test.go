@@ -0,0 +1,123 @@
+package tsv_test
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/syb-devs/gotools/tsv"
+	"github.com/syb-devs/gotools/validate"
+)
+
+const badUsers = `name	age	range	active
+john doe	35	-10	1
+jane doe	abc	-10	1
+too	few
+jim doe	40	x	maybe
+joe doe	25	3	0`
+
+func TestParseError(t *testing.T) {
+	r := tsv.NewReader(strings.NewReader(badUsers))
+	r.ReadHeader()
+	r.Next()
+	r.Next()
+	err := r.Decode(&user{})
+
+	perr, ok := err.(*tsv.ParseError)
+	if !ok {
+		t.Fatalf("expecting a *tsv.ParseError, got %#+v", err)
+	}
+	want := &tsv.ParseError{Line: 3, Column: 1, Name: "age", Field: "Age", Value: "abc", Err: perr.Err}
+	if !reflect.DeepEqual(perr, want) {
+		t.Errorf("mismatch\nhave %#+v\nwant %#+v", perr, want)
+	}
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("expecting error to wrap strconv.ErrSyntax, got %v", perr.Err)
+	}
+
+	if r.Next() {
+		t.Fatal("expecting Next to fail on a row with missing columns")
+	}
+	if !errors.Is(r.Err(), tsv.ErrColNumMismatch) {
+		t.Errorf("expecting column number mismatch, got %v", r.Err())
+	}
+	if !r.Next() {
+		t.Fatalf("expecting reader to recover after a column number mismatch, got %v", r.Err())
+	}
+	if r.Line() != 5 {
+		t.Errorf("expecting line 5, got %d", r.Line())
+	}
+}
+
+func TestLenient(t *testing.T) {
+	r := tsv.NewReader(strings.NewReader(badUsers))
+	r.Lenient = true
+	r.ReadHeader()
+
+	var names []string
+	for r.Next() {
+		u := &user{}
+		if err := r.Decode(u); err != nil {
+			continue
+		}
+		names = append(names, u.Name)
+	}
+	if r.Err() != nil {
+		t.Errorf("unexpected error: %v", r.Err())
+	}
+	if want := []string{"john doe", "joe doe"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("decoded rows mismatch\nhave %v\nwant %v", names, want)
+	}
+
+	type pos struct{ line, col int }
+	var have []pos
+	for _, err := range r.Errors() {
+		have = append(have, pos{err.Line, err.Column})
+	}
+	want := []pos{{3, 1}, {4, -1}, {5, 2}, {5, 3}}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("errors mismatch\nhave %v\nwant %v", have, want)
+	}
+}
+
+type member struct {
+	Name  string `tsv:"name" validate:"required,min=3"`
+	Age   int    `tsv:"age" validate:"min=18,max=130"`
+	Plan  string `tsv:"plan" validate:"oneof=free|pro"`
+	Email string `tsv:"email" validate:"omitempty,regex=^[^@]+@[^@]+$"`
+}
+
+const members = `name	age	plan	email
+john doe	35	pro	john@example.com
+jo	15	gold	jo
+jane doe	29	free	`
+
+func TestValidation(t *testing.T) {
+	r := tsv.NewReader(strings.NewReader(members))
+	r.Lenient = true
+	r.ReadHeader()
+	have, err := tsv.ReadAll[member](r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(have) != 2 || have[1].Name != "jane doe" {
+		t.Errorf("expecting john and jane, got %+v", have)
+	}
+
+	var cols []string
+	for _, err := range r.Errors() {
+		if err.Line != 3 {
+			t.Errorf("expecting errors at line 3, got %v", err)
+		}
+		cols = append(cols, err.Name)
+	}
+	if want := []string{"name", "age", "plan", "email"}; !reflect.DeepEqual(cols, want) {
+		t.Errorf("columns mismatch\nhave %v\nwant %v", cols, want)
+	}
+	want := `line 3, column 1 (age), field Age: invalid value "15": value 15 is below the minimum of 18`
+	if err := r.Errors()[1]; err.Error() != want || !errors.Is(err, validate.ErrMin) {
+		t.Errorf("error mismatch\nhave %v\nwant %s", err, want)
+	}
+}
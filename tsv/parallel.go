@@ -0,0 +1,188 @@
+package tsv
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"runtime"
+	"sync"
+)
+
+const (
+	// DefaultBatchSize is the number of rows handed to a worker at once by ForEachParallel
+	DefaultBatchSize = 512
+)
+
+// ParallelOptions configures the parallel decoding of ForEachParallel and ReadAllParallel
+type ParallelOptions struct {
+	// Workers is the number of goroutines decoding rows. If zero, runtime.GOMAXPROCS(0) is used
+	Workers int
+
+	// BatchSize is the number of rows handed to a worker at once. If zero, DefaultBatchSize is used
+	BatchSize int
+
+	// MaxBatches bounds the number of batches being read, decoded or waiting
+	// to be delivered, and so the memory used. If zero, twice the number of workers is used
+	MaxBatches int
+}
+
+func (o ParallelOptions) withDefaults() ParallelOptions {
+	if o.Workers <= 0 {
+		o.Workers = runtime.GOMAXPROCS(0)
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultBatchSize
+	}
+	if o.MaxBatches <= 0 {
+		o.MaxBatches = 2 * o.Workers
+	}
+	return o
+}
+
+// batch is a group of consecutive rows decoded by a single worker
+type batch[T any] struct {
+	recs []record
+	errs []error // read errors before decoding, decoding errors after
+	vals []T
+	done chan struct{}
+}
+
+// ForEachParallel works like ForEach, but it decodes rows using a pool of
+// workers while a single goroutine reads them, which makes good use of
+// several cores on large inputs. fn is still called from a single goroutine,
+// with the values in input order. Custom converters and unmarshalers must be
+// safe for concurrent use
+func ForEachParallel[T any](ctx context.Context, r *Reader, opts ParallelOptions, fn func(T) error) error {
+	opts = opts.withDefaults()
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if _, err := structValue(reflect.New(t).Interface()); err != nil {
+		return err
+	}
+	pl, err := r.plan(t)
+	if err != nil {
+		return err
+	}
+	if r.Strict && pl.headerErr != nil {
+		return pl.headerErr
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	// stop and wait for every goroutine before returning, so none of them outlives the call
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	jobs := make(chan *batch[T])
+	ordered := make(chan *batch[T], opts.MaxBatches)
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range jobs {
+				decodeBatch(r, b, pl)
+				close(b.done)
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(jobs)
+		defer close(ordered)
+		readBatches(ctx, r, opts.BatchSize, jobs, ordered)
+	}()
+
+	for b := range ordered {
+		select {
+		case <-b.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		for i := range b.recs {
+			if err := b.errs[i]; err != nil {
+				if perr, ok := err.(*ParseError); ok && r.Lenient {
+					r.errs = append(r.errs, perr)
+					continue
+				}
+				if errs, ok := err.(ErrorList); ok && r.Lenient {
+					r.errs = append(r.errs, errs...)
+					continue
+				}
+				return err
+			}
+			if err := fn(b.vals[i]); err != nil {
+				return err
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.Err()
+}
+
+// ReadAllParallel works like ReadAll, decoding rows with ForEachParallel
+func ReadAllParallel[T any](ctx context.Context, r *Reader, opts ParallelOptions) ([]T, error) {
+	var all []T
+	err := ForEachParallel(ctx, r, opts, func(v T) error {
+		all = append(all, v)
+		return nil
+	})
+	return all, err
+}
+
+// readBatches reads the remaining rows in batches, sending each one to the
+// workers and, in input order, to the consumer. Row errors are kept in the
+// batch, as the consumer decides whether they are skipped
+func readBatches[T any](ctx context.Context, r *Reader, size int, jobs, ordered chan<- *batch[T]) {
+	for {
+		b := &batch[T]{done: make(chan struct{})}
+		stop := false
+		for len(b.recs) < size {
+			row, err := r.Read()
+			if err == io.EOF {
+				stop = true
+				break
+			}
+			if err != nil {
+				b.recs = append(b.recs, record{})
+				b.errs = append(b.errs, err)
+				if _, ok := err.(*ParseError); !ok || !r.Lenient {
+					stop = true
+					break
+				}
+				continue
+			}
+			b.recs = append(b.recs, record{fields: row, nulls: r.nulls, line: r.line, file: r.rec.file})
+			b.errs = append(b.errs, nil)
+		}
+		if len(b.recs) > 0 {
+			select {
+			case ordered <- b:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- b:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if stop {
+			return
+		}
+	}
+}
+
+// decodeBatch decodes the rows of the batch that were read without errors
+func decodeBatch[T any](r *Reader, b *batch[T], pl *plan) {
+	b.vals = make([]T, len(b.recs))
+	for i, rec := range b.recs {
+		if b.errs[i] != nil {
+			continue
+		}
+		b.errs[i] = r.decodeRow(rec, reflect.ValueOf(&b.vals[i]).Elem(), pl)
+	}
+}
@@ -0,0 +1,115 @@
+package tsv_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/syb-devs/gotools/tsv"
+)
+
+func TestReadAllParallel(t *testing.T) {
+	in := benchRows(5000)
+
+	r := tsv.NewReader(strings.NewReader(in))
+	r.ReadHeader()
+	want, err := tsv.ReadAll[report](r)
+	if err != nil {
+		t.Fatalf("unexpected error reading sequentially: %v", err)
+	}
+
+	for _, opts := range []tsv.ParallelOptions{{}, {Workers: 1}, {Workers: 7, BatchSize: 3, MaxBatches: 2}} {
+		r := tsv.NewReader(strings.NewReader(in))
+		r.ReadHeader()
+		have, err := tsv.ReadAllParallel[report](context.Background(), r, opts)
+		if err != nil {
+			t.Fatalf("%+v: unexpected error: %v", opts, err)
+		}
+		if !reflect.DeepEqual(have, want) {
+			t.Errorf("%+v: parallel and sequential results differ", opts)
+		}
+	}
+}
+
+func TestForEachParallelErrors(t *testing.T) {
+	opts := tsv.ParallelOptions{Workers: 3, BatchSize: 1}
+
+	r := tsv.NewReader(strings.NewReader(badUsers))
+	r.ReadHeader()
+	var perr *tsv.ParseError
+	_, err := tsv.ReadAllParallel[user](context.Background(), r, opts)
+	if !errors.As(err, &perr) || perr.Line != 3 {
+		t.Errorf("expecting a parse error at line 3, got %v", err)
+	}
+
+	r = tsv.NewReader(strings.NewReader(badUsers))
+	r.Lenient = true
+	r.ReadHeader()
+	have, err := tsv.ReadAllParallel[user](context.Background(), r, opts)
+	if err != nil {
+		t.Fatalf("unexpected error in lenient mode: %v", err)
+	}
+	if len(have) != 2 || have[1].Name != "joe doe" {
+		t.Errorf("expecting john and joe, got %+v", have)
+	}
+	var lines []int
+	for _, err := range r.Errors() {
+		lines = append(lines, err.Line)
+	}
+	if want := []int{3, 4, 5, 5}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("error lines mismatch\nhave %v\nwant %v", lines, want)
+	}
+}
+
+func TestForEachParallelStop(t *testing.T) {
+	errStop := errors.New("stop")
+	r := tsv.NewReader(strings.NewReader(benchRows(10000)))
+	r.ReadHeader()
+	var seen int
+	err := tsv.ForEachParallel(context.Background(), r, tsv.ParallelOptions{BatchSize: 10}, func(rep report) error {
+		if rep.ID != int64(seen) {
+			return fmt.Errorf("expecting ID %d, got %d", seen, rep.ID)
+		}
+		seen++
+		if seen == 100 {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop {
+		t.Errorf("expecting stop error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r = tsv.NewReader(strings.NewReader(benchRows(10000)))
+	r.ReadHeader()
+	if _, err := tsv.ReadAllParallel[report](ctx, r, tsv.ParallelOptions{}); err != context.Canceled {
+		t.Errorf("expecting context canceled error, got %v", err)
+	}
+}
+
+func BenchmarkSequential(b *testing.B) {
+	r := tsv.NewReader(strings.NewReader(benchRows(b.N)))
+	r.ReadHeader()
+	b.ReportAllocs()
+	b.ResetTimer()
+	err := tsv.ForEach(context.Background(), r, func(report) error { return nil })
+	if err != nil {
+		b.Fatal(err)
+	}
+}
+
+func BenchmarkParallel(b *testing.B) {
+	r := tsv.NewReader(strings.NewReader(benchRows(b.N)))
+	r.ReadHeader()
+	b.ReportAllocs()
+	b.ResetTimer()
+	err := tsv.ForEachParallel(context.Background(), r, tsv.ParallelOptions{}, func(report) error { return nil })
+	if err != nil {
+		b.Fatal(err)
+	}
+}
@@ -0,0 +1,279 @@
+package tsv
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/syb-devs/gotools/structinfo"
+	"github.com/syb-devs/gotools/validate"
+)
+
+// fieldSpec holds the tsv mapping of a struct field, which only depends on the
+// struct type. Fields of nested structs have a multi level index and a dotted name
+type fieldSpec struct {
+	index []int
+	name  string
+	tag   fieldTag
+	rules validate.Rules
+}
+
+// typeSpecs caches the field specs of every struct type seen so far
+var typeSpecs sync.Map
+
+// fieldSpecs returns the cached field specs for the struct type t, extracting them on first use
+func fieldSpecs(t reflect.Type) ([]fieldSpec, error) {
+	if specs, ok := typeSpecs.Load(t); ok {
+		return specs.([]fieldSpec), nil
+	}
+	specs, err := collectSpecs(t, nil, "", "", map[reflect.Type]bool{})
+	if err != nil {
+		return nil, err
+	}
+	specs = dominantSpecs(specs)
+	typeSpecs.Store(t, specs)
+	return specs, nil
+}
+
+// collectSpecs walks the fields of t, flattening embedded structs the way Go
+// promotes their fields, and mapping named nested structs to prefixed columns.
+// The column prefix of a nested struct defaults to its name followed by a dot,
+// and can be changed with the prefix tag option
+func collectSpecs(t reflect.Type, index []int, namePrefix, colPrefix string, visited map[reflect.Type]bool) ([]fieldSpec, error) {
+	info, err := structinfo.ExtractType(t, "tsv")
+	if err != nil {
+		return nil, err
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	visited[t] = true
+	defer delete(visited, t)
+
+	var specs []fieldSpec
+	for _, fi := range info.Fields.All {
+		if fi.Tag == "-" {
+			continue
+		}
+		sf := t.Field(fi.Index)
+		idx := append(index[:len(index):len(index)], fi.Index)
+		name := namePrefix + fi.Name
+		tag := parseTag(fi.Tag)
+
+		if ft := indirectType(sf.Type); isNested(ft) {
+			if visited[ft] {
+				continue
+			}
+			prefix, ok := tag.opts["prefix"]
+			if fi.Anonymous && tag.name == "" {
+				if !fi.Exported && sf.Type.Kind() == reflect.Ptr {
+					// nil pointers to unexported structs can't be allocated
+					continue
+				}
+			} else {
+				if !fi.Exported || (tag.name == "" && !ok) {
+					continue
+				}
+				if !ok {
+					prefix = tag.name + "."
+				}
+			}
+			nested, err := collectSpecs(ft, idx, name+".", colPrefix+prefix, visited)
+			if err != nil {
+				return nil, err
+			}
+			specs = append(specs, nested...)
+			continue
+		}
+
+		if !fi.Exported || fi.Tag == "" {
+			continue
+		}
+		if tag.name != "" {
+			tag.name = colPrefix + tag.name
+			for i, alias := range tag.aliases {
+				tag.aliases[i] = colPrefix + alias
+			}
+		}
+		rules, err := validate.Parse(sf.Tag.Get("validate"))
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", name, err)
+		}
+		specs = append(specs, fieldSpec{index: idx, name: name, tag: tag, rules: rules})
+	}
+	return specs, nil
+}
+
+// dominantSpecs resolves fields mapped to the same column as Go resolves
+// promoted fields: the shallowest one wins, and if several fields share the
+// shallowest depth none of them is used
+func dominantSpecs(specs []fieldSpec) []fieldSpec {
+	type dominance struct {
+		depth int
+		count int
+	}
+	cols := make(map[string]*dominance, len(specs))
+	for _, spec := range specs {
+		key := spec.colKey()
+		d, ok := cols[key]
+		switch {
+		case !ok || len(spec.index) < d.depth:
+			cols[key] = &dominance{depth: len(spec.index), count: 1}
+		case len(spec.index) == d.depth:
+			d.count++
+		}
+	}
+	var dominant []fieldSpec
+	for _, spec := range specs {
+		d := cols[spec.colKey()]
+		if len(spec.index) == d.depth && d.count == 1 {
+			dominant = append(dominant, spec)
+		}
+	}
+	return dominant
+}
+
+func (spec fieldSpec) colKey() string {
+	if spec.tag.name != "" {
+		return spec.tag.name
+	}
+	return "#" + strconv.Itoa(spec.tag.col)
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// isNested tells whether values of type t are decoded field by field, as
+// opposed to struct types decoded from a single value like time.Time
+func isNested(t reflect.Type) bool {
+	pt := reflect.PointerTo(t)
+	return t.Kind() == reflect.Struct && t != timeType &&
+		!pt.Implements(unmarshalerType) && !pt.Implements(marshalerType) &&
+		!pt.Implements(textUnmarshalerType) && !pt.Implements(textMarshalerType)
+}
+
+// fieldByIndex returns the nested field of v at index. Nil struct pointers
+// found along the way are allocated if alloc is true, otherwise an invalid
+// value is returned
+func fieldByIndex(v reflect.Value, index []int, alloc bool) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc || !v.CanSet() {
+					return reflect.Value{}
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// colField maps a column of the header to a struct field
+type colField struct {
+	col       int
+	index     []int
+	name      string
+	opts      valueOpts
+	def       string
+	hasDef    bool
+	omitempty bool
+	rules     validate.Rules
+}
+
+// plan is the column to field mapping of a struct type for a given header
+type plan struct {
+	fields    []colField
+	headerErr error
+}
+
+// planConfig holds the reader and writer settings that plans depend on
+type planConfig struct {
+	sliceSep string
+	fold     bool
+	convs    *Converters
+}
+
+// plans caches the plans for one header, keyed by struct type
+type plans map[reflect.Type]*plan
+
+func (p plans) get(t reflect.Type, header []string, cfg planConfig) (*plan, error) {
+	if pl, ok := p[t]; ok {
+		return pl, nil
+	}
+	specs, err := fieldSpecs(t)
+	if err != nil {
+		return nil, err
+	}
+	convs := cfg.convs
+	if convs == nil {
+		convs = DefaultConverters
+	}
+	types := convs.types()
+	pl := &plan{}
+	used := make([]bool, len(header))
+	var missing []string
+	for _, spec := range specs {
+		col := fieldCol(header, spec.tag, cfg.fold)
+		present := col >= 0 && (header == nil || col < len(header))
+		if !present && spec.tag.required() {
+			missing = append(missing, spec.tag.String())
+		}
+		if col < 0 {
+			continue
+		}
+		if col < len(used) {
+			used[col] = true
+		}
+		opts, err := newValueOpts(spec.tag, cfg.sliceSep, convs, types)
+		if err != nil {
+			return nil, err
+		}
+		def, hasDef := spec.tag.opts["default"]
+		_, omitempty := spec.tag.opts["omitempty"]
+		pl.fields = append(pl.fields, colField{
+			col:       col,
+			index:     spec.index,
+			name:      spec.name,
+			opts:      opts,
+			def:       def,
+			hasDef:    hasDef,
+			omitempty: omitempty,
+			rules:     spec.rules,
+		})
+	}
+
+	var unknown []string
+	for i, u := range used {
+		if !u {
+			unknown = append(unknown, header[i])
+		}
+	}
+	if len(missing) > 0 || len(unknown) > 0 {
+		pl.headerErr = &HeaderError{Missing: missing, Unknown: unknown}
+	}
+	p[t] = pl
+	return pl, nil
+}
+
+// structValue dereferences v until reaching the struct value
+func structValue(v interface{}) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return rv, structinfo.ErrInvalidType
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return rv, structinfo.ErrInvalidType
+	}
+	return rv, nil
+}
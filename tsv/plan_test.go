@@ -0,0 +1,79 @@
+package tsv_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/syb-devs/gotools/tsv"
+)
+
+type contact struct {
+	Name  string `tsv:"name,required"`
+	Email string `tsv:"email,required,alias:mail|e-mail"`
+	Phone string `tsv:"phone"`
+	Notes string `tsv:",col:3,required"`
+}
+
+var headerTests = []struct {
+	in              string
+	caseInsensitive bool
+	out             *contact
+	err             error
+}{
+	{
+		in:  "name\temail\tphone\tnotes\njohn\tj@example.com\t555\tvip",
+		out: &contact{"john", "j@example.com", "555", "vip"},
+	},
+	{
+		in:  "name\te-mail\tphone\njohn\tj@example.com\t555",
+		out: &contact{},
+		err: &tsv.HeaderError{Missing: []string{"col:3"}},
+	},
+	{
+		in:  "Name\tMail\tPhone\tAge\njohn\tj@example.com\t555\t35",
+		out: &contact{},
+		err: &tsv.HeaderError{Missing: []string{"name", "email"}, Unknown: []string{"Name", "Mail", "Phone"}},
+	},
+	{
+		in:              "Name\tMail\tPhone\tAge\nJohn\tj@example.com\t555\t35",
+		caseInsensitive: true,
+		out:             &contact{"John", "j@example.com", "555", "35"},
+	},
+}
+
+func TestStrictHeader(t *testing.T) {
+	for i, test := range headerTests {
+		r := tsv.NewReader(strings.NewReader(test.in))
+		r.Strict = true
+		r.CaseInsensitive = test.caseInsensitive
+		r.ReadHeader()
+		r.Next()
+		have := &contact{}
+		err := r.Decode(have)
+		if !reflect.DeepEqual(err, test.err) {
+			t.Errorf("#%d: error mismatch\nhave %#+v\nwant %#+v", i, err, test.err)
+		}
+		if !reflect.DeepEqual(have, test.out) {
+			t.Errorf("#%d: mismatch\nhave %#+v\nwant %#+v", i, have, test.out)
+		}
+	}
+}
+
+func TestValidateHeader(t *testing.T) {
+	r := tsv.NewReader(strings.NewReader("mail\tname\tcomment\nj@example.com\tjohn\thi"))
+	r.ReadHeader()
+	want := &tsv.HeaderError{Missing: []string{"col:3"}, Unknown: []string{"comment"}}
+	if err := r.ValidateHeader(&contact{}); !reflect.DeepEqual(err, want) {
+		t.Errorf("error mismatch\nhave %#+v\nwant %#+v", err, want)
+	}
+
+	r.Next()
+	have := &contact{}
+	if err := r.Decode(have); err != nil {
+		t.Fatalf("unexpected error decoding in non strict mode: %v", err)
+	}
+	if want := (&contact{Name: "john", Email: "j@example.com"}); !reflect.DeepEqual(have, want) {
+		t.Errorf("mismatch\nhave %#+v\nwant %#+v", have, want)
+	}
+}
@@ -0,0 +1,172 @@
+package tsv
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	tstrings "github.com/syb-devs/gotools/strings"
+)
+
+var (
+	// ErrUnknownColumn is returned by Record accessors when the header has no such column
+	ErrUnknownColumn = errors.New("unknown column")
+
+	// ErrNilMap is returned by DecodeMap when the destination map is nil
+	ErrNilMap = errors.New("nil destination map")
+)
+
+// Record is a row along with its header, giving access to the cells by column
+// name without a struct to decode into
+type Record struct {
+	header []string
+	row    []string
+	nulls  []bool
+	line   int
+}
+
+// Record returns the current row as a Record
+func (r *Reader) Record() (Record, error) {
+	if r.err != nil {
+		return Record{}, r.err
+	}
+	if len(r.row) == 0 {
+		return Record{}, ErrEmptyRow
+	}
+	return Record{header: r.header, row: r.row, nulls: r.nulls, line: r.line}, nil
+}
+
+// DecodeMap decodes the current row into a map keyed by column name. The map
+// must not be nil
+func (r *Reader) DecodeMap(dest map[string]string) error {
+	if dest == nil {
+		return ErrNilMap
+	}
+	rec, err := r.Record()
+	if err != nil {
+		return err
+	}
+	for i, name := range rec.header {
+		if i < len(rec.row) {
+			dest[name] = rec.row[i]
+		}
+	}
+	return nil
+}
+
+// Columns returns the column names of the record
+func (rec Record) Columns() []string {
+	return rec.header
+}
+
+// Map returns the record as a map keyed by column name
+func (rec Record) Map() map[string]string {
+	m := make(map[string]string, len(rec.header))
+	for i, name := range rec.header {
+		if i < len(rec.row) {
+			m[name] = rec.row[i]
+		}
+	}
+	return m
+}
+
+// Lookup returns the value of the column, and whether the column exists
+func (rec Record) Lookup(col string) (string, bool) {
+	i := colIndex(rec.header, col, false)
+	if i < 0 || i >= len(rec.row) {
+		return "", false
+	}
+	return rec.row[i], true
+}
+
+// Get returns the value of the column, or an empty string if it doesn't exist
+func (rec Record) Get(col string) string {
+	val, _ := rec.Lookup(col)
+	return val
+}
+
+// IsNull tells whether the column holds the NULL marker of the dialect
+func (rec Record) IsNull(col string) bool {
+	i := colIndex(rec.header, col, false)
+	return i >= 0 && i < len(rec.nulls) && rec.nulls[i]
+}
+
+// Int returns the value of the column as an int
+func (rec Record) Int(col string) (int, error) {
+	i, err := rec.Int64(col)
+	return int(i), err
+}
+
+// Int64 returns the value of the column as an int64
+func (rec Record) Int64(col string) (int64, error) {
+	var i int64
+	err := rec.parse(col, func(val string) (err error) {
+		i, err = strconv.ParseInt(val, 10, 64)
+		return err
+	})
+	return i, err
+}
+
+// Uint64 returns the value of the column as an uint64
+func (rec Record) Uint64(col string) (uint64, error) {
+	var u uint64
+	err := rec.parse(col, func(val string) (err error) {
+		u, err = strconv.ParseUint(val, 10, 64)
+		return err
+	})
+	return u, err
+}
+
+// Float returns the value of the column as a float64
+func (rec Record) Float(col string) (float64, error) {
+	var f float64
+	err := rec.parse(col, func(val string) (err error) {
+		f, err = strconv.ParseFloat(val, 64)
+		return err
+	})
+	return f, err
+}
+
+// Bool returns the value of the column as a bool, using the lenient rules of
+// strings.ParseBool, which understands values like yes, on or enabled
+func (rec Record) Bool(col string) (bool, error) {
+	var b bool
+	err := rec.parse(col, func(val string) (err error) {
+		b, err = tstrings.ParseBool(val)
+		return err
+	})
+	return b, err
+}
+
+// Time returns the value of the column as a time, parsed with the given layout
+func (rec Record) Time(col, layout string) (time.Time, error) {
+	var t time.Time
+	err := rec.parse(col, func(val string) (err error) {
+		t, err = time.Parse(layout, val)
+		return err
+	})
+	return t, err
+}
+
+// List returns the value of the column as a comma separated list of trimmed strings
+func (rec Record) List(col string) ([]string, error) {
+	var l []string
+	err := rec.parse(col, func(val string) (err error) {
+		l, err = tstrings.ParseList(val)
+		return err
+	})
+	return l, err
+}
+
+// parse calls fn with the value of the column, wrapping its error in a *ParseError
+func (rec Record) parse(col string, fn func(val string) error) error {
+	i := colIndex(rec.header, col, false)
+	if i < 0 || i >= len(rec.row) {
+		return &ParseError{Line: rec.line, Column: -1, Err: fmt.Errorf("%w: %s", ErrUnknownColumn, col)}
+	}
+	if err := fn(rec.row[i]); err != nil {
+		return &ParseError{Line: rec.line, Column: i, Name: col, Value: rec.row[i], Err: err}
+	}
+	return nil
+}
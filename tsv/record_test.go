@@ -0,0 +1,64 @@
+package tsv_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/syb-devs/gotools/tsv"
+)
+
+const people = "name\tage\tscore\tactive\tborn\ttags\n" +
+	"john\t35\t7.5\tyes\t1980-05-17\ta, b ,c\n"
+
+func TestRecord(t *testing.T) {
+	r := tsv.NewReader(strings.NewReader(people))
+	r.ReadHeader()
+	r.Next()
+	rec, err := r.Record()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if rec.Get("name") != "john" || rec.Get("missing") != "" {
+		t.Errorf("unexpected Get results: %q, %q", rec.Get("name"), rec.Get("missing"))
+	}
+	if age, err := rec.Int("age"); age != 35 || err != nil {
+		t.Errorf("expecting age 35, got %d, %v", age, err)
+	}
+	if score, err := rec.Float("score"); score != 7.5 || err != nil {
+		t.Errorf("expecting score 7.5, got %v, %v", score, err)
+	}
+	if active, err := rec.Bool("active"); !active || err != nil {
+		t.Errorf("expecting active, got %v, %v", active, err)
+	}
+	born, err := rec.Time("born", "2006-01-02")
+	if !born.Equal(time.Date(1980, 5, 17, 0, 0, 0, 0, time.UTC)) || err != nil {
+		t.Errorf("unexpected born date %v, %v", born, err)
+	}
+	if tags, err := rec.List("tags"); !reflect.DeepEqual(tags, []string{"a", "b", "c"}) || err != nil {
+		t.Errorf("unexpected tags %q, %v", tags, err)
+	}
+
+	var perr *tsv.ParseError
+	if _, err := rec.Int("name"); !errors.As(err, &perr) || perr.Column != 0 || perr.Line != 2 {
+		t.Errorf("expecting a parse error at line 2, column 0, got %v", err)
+	}
+	if _, err := rec.Int("missing"); !errors.Is(err, tsv.ErrUnknownColumn) {
+		t.Errorf("expecting unknown column error, got %v", err)
+	}
+
+	want := map[string]string{"name": "john", "age": "35", "score": "7.5", "active": "yes", "born": "1980-05-17", "tags": "a, b ,c"}
+	if m := rec.Map(); !reflect.DeepEqual(m, want) {
+		t.Errorf("map mismatch\nhave %v\nwant %v", m, want)
+	}
+	m := map[string]string{}
+	if err := r.DecodeMap(m); err != nil || !reflect.DeepEqual(m, want) {
+		t.Errorf("DecodeMap mismatch\nhave %v, %v\nwant %v", m, err, want)
+	}
+	if err := r.DecodeMap(nil); err != tsv.ErrNilMap {
+		t.Errorf("expecting nil map error, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package tsv
+
+import (
+	"io"
+)
+
+// Checkpoint is the position of a Reader after a row, from where reading can
+// be resumed with Resume
+type Checkpoint struct {
+	Offset int64    // Input offset after the row
+	Line   int      // Line where the row ends
+	Row    int      // Row number, as returned by RowNumber
+	Header []string // Header of the input
+}
+
+// RowNumber returns the number of the current row, starting at 1 after the
+// header. Malformed rows and rows discarded by Skip are counted
+func (r *Reader) RowNumber() int {
+	return r.rows
+}
+
+// Offset returns the input offset right after the current row, which is
+// relative to the current part for multi-part readers, and to the
+// decompressed data for compressed ones
+func (r *Reader) Offset() int64 {
+	return r.base + r.rec.offset
+}
+
+// Skip reads and discards the next n rows, malformed or not. It returns
+// io.EOF if the input ends before
+func (r *Reader) Skip(n int) error {
+	for i := 0; i < n; i++ {
+		_, err := r.readRow()
+		if _, ok := err.(*ParseError); ok {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Checkpoint returns the position after the current row
+func (r *Reader) Checkpoint() Checkpoint {
+	return Checkpoint{
+		Offset: r.Offset(),
+		Line:   r.rec.end,
+		Row:    r.rows,
+		Header: r.header,
+	}
+}
+
+// Resume returns a Reader that reads from rs using the given dialect,
+// carrying on from the checkpoint: rs is seeked to its offset, and the
+// header, row and line numbers are restored, so ReadHeader must not be
+// called. The input must be the same, uncompressed and in a single part
+func Resume(rs io.ReadSeeker, d Dialect, cp Checkpoint) (*Reader, error) {
+	if _, err := rs.Seek(cp.Offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	r := NewDialectReader(rs, d)
+	r.header = cp.Header
+	r.numCols = len(cp.Header)
+	r.rows = cp.Row
+	r.base = cp.Offset
+	r.lines = cp.Line
+	return r, nil
+}
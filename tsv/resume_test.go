@@ -0,0 +1,119 @@
+package tsv_test
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/syb-devs/gotools/tsv"
+)
+
+const resumeRows = "name\tage\njohn\t35\njane\t29\n\"jim\nsmith\"\t40\njoe\tabc\njack\t51\n"
+
+var skipTests = []struct {
+	skip, limit int
+	names       []string
+	rows        []int
+}{
+	{0, 0, []string{"john", "jane", "jim\nsmith", "jack"}, []int{1, 2, 3, 5}},
+	{2, 0, []string{"jim\nsmith", "jack"}, []int{3, 5}},
+	{0, 2, []string{"john", "jane"}, []int{1, 2}},
+	{1, 3, []string{"jane", "jim\nsmith"}, []int{2, 3}},
+	{5, 1, nil, nil},
+}
+
+func TestSkipLimit(t *testing.T) {
+	for _, d := range []tsv.Dialect{tsv.DefaultDialect, {Delimiter: '\t', Quote: '"', TrimSpace: true}} {
+		for i, tt := range skipTests {
+			r := tsv.NewDialectReader(strings.NewReader(resumeRows), d)
+			r.Lenient = true
+			r.Limit = tt.limit
+			r.ReadHeader()
+			if err := r.Skip(tt.skip); err != nil {
+				t.Fatalf("#%d: unexpected error skipping: %v", i, err)
+			}
+			var names []string
+			var rows []int
+			for r.Next() {
+				var p struct {
+					Name string `tsv:"name"`
+					Age  int    `tsv:"age"`
+				}
+				if err := r.Decode(&p); err != nil {
+					continue
+				}
+				names = append(names, p.Name)
+				rows = append(rows, r.RowNumber())
+			}
+			if !reflect.DeepEqual(names, tt.names) || !reflect.DeepEqual(rows, tt.rows) {
+				t.Errorf("#%d: mismatch\nhave %q %v\nwant %q %v", i, names, rows, tt.names, tt.rows)
+			}
+		}
+	}
+
+	r := tsv.NewReader(strings.NewReader(resumeRows))
+	r.ReadHeader()
+	if err := r.Skip(10); err != io.EOF {
+		t.Errorf("expecting EOF skipping past the end, got %v", err)
+	}
+}
+
+func TestResume(t *testing.T) {
+	for _, d := range []tsv.Dialect{tsv.DefaultDialect, {Delimiter: '\t', Quote: '"', TrimSpace: true}} {
+		r := tsv.NewDialectReader(strings.NewReader(resumeRows), d)
+		r.ReadHeader()
+		r.Skip(3)
+		cp := r.Checkpoint()
+		if want := int64(strings.Index(resumeRows, "joe")); cp.Offset != want {
+			t.Errorf("%+v: offset mismatch\nhave %d\nwant %d", d, cp.Offset, want)
+		}
+		if cp.Line != 5 || cp.Row != 3 {
+			t.Errorf("%+v: expecting line 5 and row 3, got %+v", d, cp)
+		}
+
+		r, err := tsv.Resume(strings.NewReader(resumeRows), d, cp)
+		if err != nil {
+			t.Fatalf("%+v: unexpected error resuming: %v", d, err)
+		}
+		if !r.Next() {
+			t.Fatalf("%+v: unexpected error: %v", d, r.Err())
+		}
+		var perr *tsv.ParseError
+		var p user
+		if err := r.Decode(&p); !errors.As(err, &perr) || perr.Line != 6 {
+			t.Errorf("%+v: expecting a parse error at line 6, got %v", d, err)
+		}
+		if !r.Next() {
+			t.Fatalf("%+v: unexpected error: %v", d, r.Err())
+		}
+		if err := r.Decode(&p); err != nil || p.Name != "jack" || p.Age != 51 {
+			t.Errorf("%+v: expecting jack, got %+v, %v", d, p, err)
+		}
+		if r.RowNumber() != 5 || r.Line() != 7 || r.Offset() != int64(len(resumeRows)) {
+			t.Errorf("%+v: expecting row 5, line 7 and offset %d, got %d, %d and %d", d, len(resumeRows), r.RowNumber(), r.Line(), r.Offset())
+		}
+	}
+}
+
+func TestMultilineCheckpoint(t *testing.T) {
+	const in = "name\tnote\nann\t\"a\nb\nc\"\nbob\tx\n"
+	for _, d := range []tsv.Dialect{tsv.DefaultDialect, {Delimiter: '\t', Quote: '"', TrimSpace: true}} {
+		r := tsv.NewDialectReader(strings.NewReader(in), d)
+		r.ReadHeader()
+		r.Skip(1)
+		cp := r.Checkpoint()
+		if cp.Line != 4 || cp.Offset != int64(strings.Index(in, "bob")) {
+			t.Errorf("%+v: expecting line 4 and offset %d, got %+v", d, strings.Index(in, "bob"), cp)
+		}
+		r, err := tsv.Resume(strings.NewReader(in), d, cp)
+		if err != nil {
+			t.Fatalf("%+v: unexpected error resuming: %v", d, err)
+		}
+		row, err := r.Read()
+		if err != nil || row[0] != "bob" || r.Line() != 5 {
+			t.Errorf("%+v: expecting bob at line 5, got %q at line %d, %v", d, row, r.Line(), err)
+		}
+	}
+}
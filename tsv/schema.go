@@ -0,0 +1,245 @@
+package tsv
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ColumnType is the Go type inferred for the values of a column
+type ColumnType int
+
+const (
+	// TypeString is used for columns with values of mixed or unknown type
+	TypeString ColumnType = iota
+	// TypeBool is used for columns holding true and false literals
+	TypeBool
+	// TypeInt is used for columns holding integers
+	TypeInt
+	// TypeUint is used for columns holding integers too big for an int64
+	TypeUint
+	// TypeFloat is used for columns holding decimal numbers
+	TypeFloat
+	// TypeTime is used for columns holding times in one of the layouts of InferLayouts
+	TypeTime
+)
+
+// GoType returns the name of the Go type used for the column type
+func (t ColumnType) GoType() string {
+	switch t {
+	case TypeBool:
+		return "bool"
+	case TypeInt:
+		return "int64"
+	case TypeUint:
+		return "uint64"
+	case TypeFloat:
+		return "float64"
+	case TypeTime:
+		return "time.Time"
+	default:
+		return "string"
+	}
+}
+
+// InferLayouts are the time layouts tried by InferSchema, in order of preference
+var InferLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"02/01/2006",
+	"02/01/2006 15:04:05",
+}
+
+// Column describes a column of a Schema
+type Column struct {
+	Name     string
+	Type     ColumnType
+	Nullable bool   // some cells are empty or NULL
+	Layout   string // time layout, for TypeTime columns
+}
+
+// Schema describes the columns of a TSV file
+type Schema struct {
+	Columns []Column
+}
+
+// columnStats tracks the types every value seen so far for a column can have
+type columnStats struct {
+	bools, ints, uints, floats bool
+	layouts                    []string
+	values, empty              int
+}
+
+func newColumnStats() *columnStats {
+	return &columnStats{bools: true, ints: true, uints: true, floats: true, layouts: InferLayouts}
+}
+
+func (s *columnStats) add(val string, null bool) {
+	if null || val == "" {
+		s.empty++
+		return
+	}
+	s.values++
+	if s.bools {
+		_, err := strconv.ParseBool(val)
+		s.bools = err == nil && !isDigits(val)
+	}
+	// leading zeros would be lost, so such values are kept as strings
+	digits := strings.TrimLeft(val, "+-")
+	leadingZero := len(digits) > 1 && digits[0] == '0' && digits[1] != '.'
+	if s.ints || s.uints {
+		_, err := strconv.ParseInt(val, 10, 64)
+		s.ints = s.ints && err == nil && !leadingZero
+		_, err = strconv.ParseUint(val, 10, 64)
+		s.uints = s.uints && err == nil && !leadingZero
+	}
+	if s.floats {
+		f, err := strconv.ParseFloat(val, 64)
+		s.floats = err == nil && !leadingZero && !math.IsInf(f, 0) && !math.IsNaN(f)
+	}
+	var layouts []string
+	for _, layout := range s.layouts {
+		if _, err := time.Parse(layout, val); err == nil {
+			layouts = append(layouts, layout)
+		}
+	}
+	s.layouts = layouts
+}
+
+func (s *columnStats) column(name string) Column {
+	col := Column{Name: name, Nullable: s.empty > 0}
+	switch {
+	case s.values == 0:
+		col.Type = TypeString
+	case s.bools:
+		col.Type = TypeBool
+	case s.ints:
+		col.Type = TypeInt
+	case s.uints:
+		col.Type = TypeUint
+	case s.floats:
+		col.Type = TypeFloat
+	case len(s.layouts) > 0:
+		col.Type = TypeTime
+		col.Layout = s.layouts[0]
+	}
+	return col
+}
+
+// InferSchema reads the header and up to maxRows rows from r, or every row if
+// maxRows is not positive, and infers the type of each column
+func InferSchema(r *Reader, maxRows int) (*Schema, error) {
+	header, err := r.ReadHeader()
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]*columnStats, len(header))
+	for i := range stats {
+		stats[i] = newColumnStats()
+	}
+	for n := 0; maxRows <= 0 || n < maxRows; n++ {
+		if !r.Next() {
+			break
+		}
+		for i, val := range r.row {
+			stats[i].add(val, r.IsNull(i))
+		}
+	}
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	schema := &Schema{Columns: make([]Column, len(header))}
+	for i, name := range header {
+		schema.Columns[i] = stats[i].column(name)
+	}
+	return schema, nil
+}
+
+// GoStruct returns the source of a Go struct type with the given name and a
+// field for every column, tagged to be decoded by Reader.Decode. Nullable
+// columns other than strings are mapped to pointers. If pkg is not empty,
+// the package clause and imports are included, making a complete file
+func (s *Schema) GoStruct(pkg, name string) ([]byte, error) {
+	var buf bytes.Buffer
+	if pkg != "" {
+		fmt.Fprintf(&buf, "package %s\n\n", pkg)
+		if s.hasTime() {
+			buf.WriteString("import \"time\"\n\n")
+		}
+	}
+	fmt.Fprintf(&buf, "type %s struct {\n", name)
+	used := make(map[string]int, len(s.Columns))
+	for i, col := range s.Columns {
+		field := fieldName(col.Name)
+		if used[field]++; used[field] > 1 {
+			field += strconv.Itoa(used[field])
+		}
+		typ := col.Type.GoType()
+		if col.Nullable && col.Type != TypeString {
+			typ = "*" + typ
+		}
+		tag := col.Name
+		if tag == "" || tag == "-" || strings.ContainsAny(tag, ",:=`\"") {
+			tag = ",col:" + strconv.Itoa(i)
+		}
+		if col.Type == TypeTime {
+			tag += ",layout=" + col.Layout
+		}
+		fmt.Fprintf(&buf, "\t%s %s `tsv:%q`\n", field, typ, tag)
+	}
+	buf.WriteString("}\n")
+	return format.Source(buf.Bytes())
+}
+
+func (s *Schema) hasTime() bool {
+	for _, col := range s.Columns {
+		if col.Type == TypeTime {
+			return true
+		}
+	}
+	return false
+}
+
+// initialisms are written in upper case in field names, as golint suggests
+var initialisms = map[string]bool{
+	"API": true, "HTML": true, "HTTP": true, "ID": true, "IP": true, "JSON": true,
+	"SKU": true, "SQL": true, "URL": true, "UUID": true, "XML": true,
+}
+
+// fieldName returns an exported Go identifier for the column name
+func fieldName(col string) string {
+	words := strings.FieldsFunc(col, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, w := range words {
+		if upper := strings.ToUpper(w); initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		runes := []rune(w)
+		b.WriteRune(unicode.ToUpper(runes[0]))
+		b.WriteString(string(runes[1:]))
+	}
+	name := b.String()
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = "Col" + name
+	}
+	return name
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
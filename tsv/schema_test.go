@@ -0,0 +1,69 @@
+package tsv_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/syb-devs/gotools/tsv"
+)
+
+const feed = "id\tcustomer name\tzip\tactive\tprice\tbig\tdelta\tcreated\tshipped\tnotes\t2nd,col\n" +
+	"1\tJohn\t01234\ttrue\t10.5\t18446744073709551615\t-3\t2015-12-24\t2015-12-24T10:00:00Z\t\tx\n" +
+	"2\tJane\t45678\tfalse\t7\t1\t\t2016-01-02\t\t\ty\n"
+
+func TestInferSchema(t *testing.T) {
+	r := tsv.NewReader(strings.NewReader(feed))
+	schema, err := tsv.InferSchema(r, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []tsv.Column{
+		{Name: "id", Type: tsv.TypeInt},
+		{Name: "customer name", Type: tsv.TypeString},
+		{Name: "zip", Type: tsv.TypeString},
+		{Name: "active", Type: tsv.TypeBool},
+		{Name: "price", Type: tsv.TypeFloat},
+		{Name: "big", Type: tsv.TypeUint},
+		{Name: "delta", Type: tsv.TypeInt, Nullable: true},
+		{Name: "created", Type: tsv.TypeTime, Layout: "2006-01-02"},
+		{Name: "shipped", Type: tsv.TypeTime, Nullable: true, Layout: "2006-01-02T15:04:05.999999999Z07:00"},
+		{Name: "notes", Type: tsv.TypeString, Nullable: true},
+		{Name: "2nd,col", Type: tsv.TypeString},
+	}
+	if !reflect.DeepEqual(schema.Columns, want) {
+		t.Errorf("mismatch\nhave %+v\nwant %+v", schema.Columns, want)
+	}
+
+	src, err := schema.GoStruct("feeds", "Feed")
+	if err != nil {
+		t.Fatalf("unexpected error generating struct: %v", err)
+	}
+	wantSrc := "package feeds\n\nimport \"time\"\n\ntype Feed struct {\n" +
+		"\tID           int64      `tsv:\"id\"`\n" +
+		"\tCustomerName string     `tsv:\"customer name\"`\n" +
+		"\tZip          string     `tsv:\"zip\"`\n" +
+		"\tActive       bool       `tsv:\"active\"`\n" +
+		"\tPrice        float64    `tsv:\"price\"`\n" +
+		"\tBig          uint64     `tsv:\"big\"`\n" +
+		"\tDelta        *int64     `tsv:\"delta\"`\n" +
+		"\tCreated      time.Time  `tsv:\"created,layout=2006-01-02\"`\n" +
+		"\tShipped      *time.Time `tsv:\"shipped,layout=2006-01-02T15:04:05.999999999Z07:00\"`\n" +
+		"\tNotes        string     `tsv:\"notes\"`\n" +
+		"\tCol2ndCol    string     `tsv:\",col:10\"`\n" +
+		"}\n"
+	if string(src) != wantSrc {
+		t.Errorf("source mismatch\nhave:\n%s\nwant:\n%s", src, wantSrc)
+	}
+}
+
+func TestInferSchemaSample(t *testing.T) {
+	r := tsv.NewReader(strings.NewReader("n\n1\nx\n"))
+	schema, err := tsv.InferSchema(r, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if schema.Columns[0].Type != tsv.TypeInt {
+		t.Errorf("expecting only the first row to be sampled, got %+v", schema.Columns[0])
+	}
+}
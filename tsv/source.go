@@ -0,0 +1,44 @@
+package tsv
+
+import (
+	"io"
+)
+
+// RowSource provides the rows of a Reader created with NewSourceReader, for
+// inputs other than delimited text, like spreadsheets
+type RowSource interface {
+	// ReadRow returns the cells of the next row and the line, or row number,
+	// where it starts. It returns io.EOF when there are no more rows
+	ReadRow() (cells []string, line int, err error)
+}
+
+// NewSourceReader returns a Reader that reads the rows of src, which gets the
+// whole Reader API: headers, struct decoding, lenient mode and so on. Offsets
+// are always 0, so checkpoints can't be resumed. If src is an io.Closer,
+// closing the Reader closes it
+func NewSourceReader(src RowSource) *Reader {
+	return &Reader{r: sourceReader{src}}
+}
+
+// sourceReader is a recordReader reading from a RowSource
+type sourceReader struct {
+	src RowSource
+}
+
+func (s sourceReader) Read(rec *record) error {
+	cells, line, err := s.src.ReadRow()
+	if err != nil {
+		return err
+	}
+	rec.fields = cells
+	rec.nulls = nil
+	rec.line, rec.end = line, line
+	return nil
+}
+
+func (s sourceReader) Close() error {
+	if c, ok := s.src.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package tsv
+
+import (
+	"context"
+	"errors"
+	"reflect"
+)
+
+// ErrInvalidSlice is returned by DecodeAll when dest is not a pointer to a slice of structs
+var ErrInvalidSlice = errors.New("destination must be a pointer to a slice of structs or struct pointers")
+
+// DecodeAll decodes every remaining row, appending the results to the slice
+// pointed to by dest, which can hold structs or pointers to structs.
+// In lenient mode, rows that fail to decode are skipped
+func (r *Reader) DecodeAll(dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return ErrInvalidSlice
+	}
+	s := v.Elem()
+	elemType := s.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return ErrInvalidSlice
+	}
+
+	for r.Next() {
+		elem := reflect.New(elemType)
+		if err := r.Decode(elem.Interface()); err != nil {
+			if r.skippable(err) {
+				continue
+			}
+			return err
+		}
+		if !isPtr {
+			elem = elem.Elem()
+		}
+		s.Set(reflect.Append(s, elem))
+	}
+	return r.Err()
+}
+
+// skippable tells whether the decoding error of a row can be skipped, which
+// is the case for row errors in lenient mode
+func (r *Reader) skippable(err error) bool {
+	_, ok := err.(ErrorList)
+	return r.Lenient && ok
+}
+
+// ReadAll decodes every remaining row of r into a slice of T, which must be a struct type
+func ReadAll[T any](r *Reader) ([]T, error) {
+	var all []T
+	err := ForEach(context.Background(), r, func(v T) error {
+		all = append(all, v)
+		return nil
+	})
+	return all, err
+}
+
+// ForEach decodes every remaining row of r into a T, which must be a struct
+// type, and calls fn with it. It stops at the first error returned by fn or
+// when ctx is done. In lenient mode, rows that fail to decode are skipped
+func ForEach[T any](ctx context.Context, r *Reader, fn func(T) error) error {
+	for r.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var v T
+		if err := r.Decode(&v); err != nil {
+			if r.skippable(err) {
+				continue
+			}
+			return err
+		}
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+	return r.Err()
+}
+
+// Stream decodes every remaining row of r into a T, which must be a struct
+// type, and sends it on ch. It stops when ctx is done. Stream doesn't close ch
+func Stream[T any](ctx context.Context, r *Reader, ch chan<- T) error {
+	return ForEach(ctx, r, func(v T) error {
+		select {
+		case ch <- v:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
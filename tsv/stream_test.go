@@ -0,0 +1,121 @@
+package tsv_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/syb-devs/gotools/tsv"
+)
+
+const users = `name	age	range	active
+john doe	35	-10	1
+jane doe	29	5	0
+jim doe	40	3	true`
+
+var allUsers = []user{
+	{"john doe", 35, -10, true, ""},
+	{"jane doe", 29, 5, false, ""},
+	{"jim doe", 40, 3, true, ""},
+}
+
+func TestDecodeAll(t *testing.T) {
+	r := tsv.NewReader(strings.NewReader(users))
+	r.ReadHeader()
+	var have []user
+	if err := r.DecodeAll(&have); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(have, allUsers) {
+		t.Errorf("mismatch\nhave %#+v\nwant %#+v", have, allUsers)
+	}
+
+	r = tsv.NewReader(strings.NewReader(users))
+	r.ReadHeader()
+	var ptrs []*user
+	if err := r.DecodeAll(&ptrs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ptrs) != len(allUsers) || !reflect.DeepEqual(*ptrs[2], allUsers[2]) {
+		t.Errorf("mismatch\nhave %#+v\nwant %#+v", ptrs, allUsers)
+	}
+
+	if err := r.DecodeAll(have); err != tsv.ErrInvalidSlice {
+		t.Errorf("expecting invalid slice error, got %v", err)
+	}
+	if err := r.DecodeAll(&[]int{}); err != tsv.ErrInvalidSlice {
+		t.Errorf("expecting invalid slice error, got %v", err)
+	}
+}
+
+func TestDecodeAllLenient(t *testing.T) {
+	r := tsv.NewReader(strings.NewReader(badUsers))
+	r.Lenient = true
+	r.ReadHeader()
+	var have []user
+	if err := r.DecodeAll(&have); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(have) != 2 || len(r.Errors()) != 4 {
+		t.Errorf("expecting 2 rows and 4 errors, got %d rows and %d errors", len(have), len(r.Errors()))
+	}
+
+	r = tsv.NewReader(strings.NewReader(badUsers))
+	r.ReadHeader()
+	if err := r.DecodeAll(&have); err == nil {
+		t.Error("expecting an error decoding bad rows in strict mode")
+	}
+}
+
+func TestReadAll(t *testing.T) {
+	r := tsv.NewReader(strings.NewReader(users))
+	r.ReadHeader()
+	have, err := tsv.ReadAll[user](r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(have, allUsers) {
+		t.Errorf("mismatch\nhave %#+v\nwant %#+v", have, allUsers)
+	}
+}
+
+func TestStream(t *testing.T) {
+	r := tsv.NewReader(strings.NewReader(users))
+	r.ReadHeader()
+	ch := make(chan user)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- tsv.Stream(context.Background(), r, ch)
+		close(ch)
+	}()
+
+	var have []user
+	for u := range ch {
+		have = append(have, u)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(have, allUsers) {
+		t.Errorf("mismatch\nhave %#+v\nwant %#+v", have, allUsers)
+	}
+}
+
+func TestForEachCancel(t *testing.T) {
+	r := tsv.NewReader(strings.NewReader(users))
+	r.ReadHeader()
+	ctx, cancel := context.WithCancel(context.Background())
+	var seen int
+	err := tsv.ForEach(ctx, r, func(u user) error {
+		seen++
+		cancel()
+		return nil
+	})
+	if err != context.Canceled {
+		t.Errorf("expecting context canceled error, got %v", err)
+	}
+	if seen != 1 {
+		t.Errorf("expecting 1 row before cancellation, got %d", seen)
+	}
+}
@@ -0,0 +1,66 @@
+package tsv
+
+import (
+	"strconv"
+	"strings"
+)
+
+// fieldTag holds the parsed contents of a tsv struct tag
+type fieldTag struct {
+	name    string
+	aliases []string
+	col     int
+	opts    map[string]string
+}
+
+// parseTag parses a tsv struct tag. The first element is the column name,
+// followed by comma separated options in the form key:value or key=value
+func parseTag(tag string) fieldTag {
+	parts := strings.Split(tag, ",")
+	ft := fieldTag{
+		name: parts[0],
+		col:  -1,
+		opts: make(map[string]string, len(parts)-1),
+	}
+	for _, opt := range parts[1:] {
+		if opt == "" {
+			continue
+		}
+		key, val := opt, ""
+		if i := strings.IndexAny(opt, ":="); i >= 0 {
+			key, val = opt[:i], opt[i+1:]
+		}
+		ft.opts[key] = val
+	}
+	if aliases := ft.opts["alias"]; aliases != "" {
+		ft.aliases = strings.Split(aliases, "|")
+	}
+	if col, ok := ft.opts["col"]; ok {
+		if i, err := strconv.Atoi(col); err == nil {
+			ft.col = i
+		}
+	}
+	return ft
+}
+
+// opt returns the value of the given tag option, or def if it's not present
+func (ft fieldTag) opt(key, def string) string {
+	if val, ok := ft.opts[key]; ok && val != "" {
+		return val
+	}
+	return def
+}
+
+// required tells whether the column is required to be present in the header
+func (ft fieldTag) required() bool {
+	_, ok := ft.opts["required"]
+	return ok
+}
+
+// String returns the column name, or its index for fields mapped by position
+func (ft fieldTag) String() string {
+	if ft.name != "" {
+		return ft.name
+	}
+	return "col:" + strconv.Itoa(ft.col)
+}
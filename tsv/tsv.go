@@ -1,14 +1,12 @@
 package tsv
 
 import (
-	"encoding/csv"
 	"errors"
 	"io"
 	"reflect"
-	"strconv"
 	"strings"
 
-	"github.com/syb-devs/gotools/structinfo"
+	"github.com/syb-devs/gotools/validate"
 )
 
 var (
@@ -18,69 +16,189 @@ var (
 	// ErrUnsuportedFieldType is returned when the underlying type of the struct field is not a supported one
 	ErrUnsuportedFieldType = errors.New("struct field has an unsupported type to decode from TSV string")
 
+	// ErrEmptyValue is returned for empty cells with the EmptyError policy
+	ErrEmptyValue = errors.New("empty value")
+
 	// ErrColNumMismatch is returned when the number of columns of the row does not match with the number of columns of the header
-	ErrColNumMismatch = errors.New("struct field has an unsupported type to decode from TSV string")
+	ErrColNumMismatch = errors.New("number of columns of the row does not match the header")
+)
+
+// EmptyPolicy tells how empty cells are decoded. NULL cells, as defined by
+// the dialect, always decode to the zero value, leaving pointers nil
+type EmptyPolicy int
+
+const (
+	// EmptyDefault decodes empty cells to the value of the default tag option
+	// if present, or to the zero value otherwise, leaving pointers nil
+	EmptyDefault EmptyPolicy = iota
+
+	// EmptyZero decodes empty cells to the zero value, ignoring default tag options
+	EmptyZero
+
+	// EmptyError makes empty cells fail with ErrEmptyValue, unless the
+	// field has the omitempty tag option, in which case it's set to the zero value
+	EmptyError
 )
 
 // Reader reads TSV data
 type Reader struct {
-	r       *csv.Reader
+	// SliceSep is the separator used to split slice fields that don't set
+	// a sep tag option. If empty, DefaultSliceSep is used. It must be set
+	// before the first call to Decode
+	SliceSep string
+
+	// Lenient makes the reader collect row errors and carry on instead of
+	// aborting. Next skips malformed rows and Decode decodes every field it
+	// can. The collected errors are available through Errors
+	Lenient bool
+
+	// Strict makes Decode check the header against the struct tags before
+	// decoding, failing with a *HeaderError when a required column is missing
+	// or a column is not mapped to any field
+	Strict bool
+
+	// CaseInsensitive makes column names match the struct tags regardless of case
+	CaseInsensitive bool
+
+	// EmptyPolicy tells how Decode handles empty cells
+	EmptyPolicy EmptyPolicy
+
+	// Converters holds the custom converters used by Decode. If nil,
+	// DefaultConverters is used. It must be set before the first call to Decode
+	Converters *Converters
+
+	// Limit, if positive, is the maximum number of rows read by Read and
+	// Next, malformed ones included. The header and the rows discarded by
+	// Skip don't count
+	Limit int
+
+	r       recordReader
+	rec     record
 	numCols int
+	line    int
+	rows    int   // rows read, for RowNumber
+	limited int   // rows read that count against the Limit
+	base    int64 // input offset where reading started, for resumed readers
+	lines   int   // lines before the input, for resumed readers
 	row     []string
+	nulls   []bool
 	header  []string
 	err     error
+	errs    ErrorList
+	plans   plans
 }
 
 // NewReader returns a new TSV Reader that reads from r
 func NewReader(r io.Reader) *Reader {
-	cr := csv.NewReader(r)
-	cr.Comma = '\t'
+	return NewDialectReader(r, DefaultDialect)
+}
+
+// NewDialectReader returns a new Reader that reads from r using the given dialect
+func NewDialectReader(r io.Reader, d Dialect) *Reader {
 	return &Reader{
-		r: cr,
+		r: newRecordReader(r, d),
 	}
 }
 
 // Read reads one record from r.
 func (r *Reader) Read() ([]string, error) {
+	if r.Limit > 0 && r.limited >= r.Limit {
+		r.err = io.EOF
+		return nil, io.EOF
+	}
+	rows := r.rows
+	row, err := r.readRow()
+	r.limited += r.rows - rows
+	return row, err
+}
+
+// readRow reads one row, counting it for RowNumber, malformed or not
+func (r *Reader) readRow() ([]string, error) {
 	row, err := r.read()
-	if err != nil {
-		r.err = err
+	r.err = err
+	if _, ok := err.(*ParseError); ok || err == nil {
+		r.rows++
 	}
 	return row, err
 }
 
 func (r *Reader) read() ([]string, error) {
-	row, err := r.r.Read()
-	if err != nil {
-		return row, err
+	if err := r.r.Read(&r.rec); err != nil {
+		if perr, ok := err.(*ParseError); ok {
+			perr.Line += r.lines
+		}
+		return nil, err
 	}
+	row := r.rec.fields
+	r.rec.line += r.lines
+	r.rec.end += r.lines
+	r.line = r.rec.line
 
 	if r.numCols > 0 && len(row) != r.numCols {
-		return []string{}, ErrColNumMismatch
+		return []string{}, &ParseError{File: r.rec.file, Line: r.line, Column: -1, Err: ErrColNumMismatch}
 	}
 
 	r.row = row
+	r.nulls = r.rec.nulls
 	r.numCols = len(row)
 	return row, nil
 }
 
 // ReadHeader reads one row from the TSV file and stores its contents as the header definition
 func (r *Reader) ReadHeader() ([]string, error) {
-	h, err := r.Read()
+	h, err := r.read()
+	r.err = err
 	if err != nil {
 		return h, err
 	}
 	r.header = h
+	r.plans = nil
 	return h, nil
 }
 
-// Next reads the next row of the TSV file
+// Next reads the next row of the TSV file. It returns false when there are no
+// more rows or an error happens, use Err to tell them apart.
+// In lenient mode, malformed rows are skipped and their errors collected
 func (r *Reader) Next() bool {
-	_, err := r.Read()
-	return err == nil
+	for {
+		_, err := r.Read()
+		if err == nil {
+			return true
+		}
+		perr, ok := err.(*ParseError)
+		if !r.Lenient || !ok {
+			return false
+		}
+		r.errs = append(r.errs, perr)
+	}
+}
+
+// Err returns the error that made Next return false, or nil if the end of the data was reached
+func (r *Reader) Err() error {
+	if r.err == io.EOF {
+		return nil
+	}
+	return r.err
+}
+
+// Errors returns the row errors collected in lenient mode
+func (r *Reader) Errors() ErrorList {
+	return r.errs
+}
+
+// IsNull tells whether the column of the current row holds the NULL marker of the dialect
+func (r *Reader) IsNull(col int) bool {
+	return col >= 0 && col < len(r.nulls) && r.nulls[col]
 }
 
-// Decode decodes the current row into dest struct
+// Line returns the line number where the current row starts
+func (r *Reader) Line() int {
+	return r.line
+}
+
+// Decode decodes the current row into dest struct. Values that can't be
+// converted are reported as a *ParseError, or as an ErrorList with all the
+// errors of the row in lenient mode
 func (r *Reader) Decode(dest interface{}) error {
 	if r.err != nil {
 		return r.err
@@ -89,87 +207,165 @@ func (r *Reader) Decode(dest interface{}) error {
 		return ErrEmptyRow
 	}
 
-	info, err := structinfo.Extract(dest, "tsv")
+	v, err := structValue(dest)
+	if err != nil {
+		return err
+	}
+	pl, err := r.plan(v.Type())
 	if err != nil {
 		return err
 	}
-	v := reflect.ValueOf(dest)
-	for v.Kind() == reflect.Ptr {
-		v = v.Elem()
+	if r.Strict && pl.headerErr != nil {
+		return pl.headerErr
 	}
-	for _, fi := range info.Fields.Exported {
-		if fi.Tag == "-" {
+
+	err = r.decodeRow(record{fields: r.row, nulls: r.nulls, line: r.line, file: r.rec.file}, v, pl)
+	if errs, ok := err.(ErrorList); ok {
+		r.errs = append(r.errs, errs...)
+	}
+	return err
+}
+
+// decodeRow decodes rec into the struct value v following the plan. It only
+// reads the reader settings, so it can be called from several goroutines
+func (r *Reader) decodeRow(rec record, v reflect.Value, pl *plan) error {
+	var errs ErrorList
+	for _, cf := range pl.fields {
+		if cf.col >= len(rec.fields) {
+			continue
+		}
+		f := fieldByIndex(v, cf.index, false)
+		if !f.IsValid() && r.setsValue(rec, cf) {
+			f = fieldByIndex(v, cf.index, true)
+		}
+		if !f.IsValid() || !f.CanSet() {
 			continue
 		}
-		f := v.Field(fi.Index)
-		if f.CanSet() {
-			col := r.getFieldCol(fi.Tag)
-			if col >= 0 {
-				if err = setVal(r.row[col], f); err != nil {
-					return err
-				}
+		var err error
+		switch val := rec.fields[cf.col]; {
+		case cf.col < len(rec.nulls) && rec.nulls[cf.col]:
+			f.Set(reflect.Zero(f.Type()))
+		case val == "":
+			err = r.setEmpty(f, cf)
+		default:
+			err = setVal(val, f, cf.opts)
+		}
+		if err == nil && cf.rules != nil {
+			err = cf.rules.Validate(f)
+		}
+		if err == ErrUnsuportedFieldType || errors.Is(err, validate.ErrInvalidRule) {
+			return err
+		}
+		if err != nil {
+			perr := r.parseError(rec, cf.col, cf.name, err)
+			if !r.Lenient {
+				return perr
 			}
+			errs = append(errs, perr)
 		}
 	}
 
+	if len(errs) > 0 {
+		return errs
+	}
 	return nil
 }
 
-func (r *Reader) getFieldCol(tag string) int {
-	parts := strings.Split(tag, ",")
-	if parts[0] != "" {
-		return r.colIndex(parts[0])
+// plan returns the cached plan of the struct type t for the current header
+func (r *Reader) plan(t reflect.Type) (*plan, error) {
+	if r.plans == nil {
+		r.plans = make(plans)
 	}
-	if len(parts) < 2 {
-		return -1
+	return r.plans.get(t, r.header, r.planConfig())
+}
+
+// ValidateHeader checks the header against the struct tags of dest, returning a
+// *HeaderError when a required column is missing or a column is not mapped to any field
+func (r *Reader) ValidateHeader(dest interface{}) error {
+	v, err := structValue(dest)
+	if err != nil {
+		return err
 	}
-	colInfo := parts[1]
-	if colInfo == "" {
-		return -1
+	pl, err := r.plan(v.Type())
+	if err != nil {
+		return err
 	}
-	ciParts := strings.Split(colInfo, ":")
-	if len(ciParts) != 2 {
-		return -1
+	return pl.headerErr
+}
+
+// setEmpty sets the value of a field for an empty cell, following the EmptyPolicy
+func (r *Reader) setEmpty(f reflect.Value, cf colField) error {
+	switch r.EmptyPolicy {
+	case EmptyError:
+		if !cf.omitempty {
+			return ErrEmptyValue
+		}
+	case EmptyDefault:
+		if cf.hasDef {
+			return setVal(cf.def, f, cf.opts)
+		}
 	}
-	i, err := strconv.Atoi(ciParts[1])
-	if err != nil {
-		return -1
+	f.Set(reflect.Zero(f.Type()))
+	return nil
+}
+
+// setsValue tells whether decoding the cell of cf sets a value or fails,
+// so that the nil struct pointers holding the field are only allocated then
+func (r *Reader) setsValue(rec record, cf colField) bool {
+	switch {
+	case cf.col < len(rec.nulls) && rec.nulls[cf.col]:
+		return false
+	case rec.fields[cf.col] != "":
+		return true
+	case r.EmptyPolicy == EmptyDefault:
+		return cf.hasDef
+	case r.EmptyPolicy == EmptyError:
+		return !cf.omitempty
 	}
-	return i
+	return false
+}
+
+func (r *Reader) planConfig() planConfig {
+	return planConfig{sliceSep: r.SliceSep, fold: r.CaseInsensitive, convs: r.Converters}
 }
 
-func (r *Reader) colIndex(col string) int {
-	for i, name := range r.header {
-		if col == name {
+func (r *Reader) parseError(rec record, col int, field string, err error) *ParseError {
+	perr := &ParseError{
+		File:   rec.file,
+		Line:   rec.line,
+		Column: col,
+		Field:  field,
+		Value:  rec.fields[col],
+		Err:    err,
+	}
+	if col < len(r.header) {
+		perr.Name = r.header[col]
+	}
+	return perr
+}
+
+// fieldCol returns the index of the column mapped to the field tag, matching
+// its name or any of its aliases, or -1 if the column is not present in the header
+func fieldCol(header []string, tag fieldTag, fold bool) int {
+	if tag.name == "" {
+		return tag.col
+	}
+	if i := colIndex(header, tag.name, fold); i >= 0 {
+		return i
+	}
+	for _, alias := range tag.aliases {
+		if i := colIndex(header, alias, fold); i >= 0 {
 			return i
 		}
 	}
 	return -1
 }
 
-func setVal(val string, field reflect.Value) error {
-	switch field.Kind() {
-	case reflect.String:
-		field.SetString(val)
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		intVal, err := strconv.Atoi(val)
-		if err != nil {
-			return err
-		}
-		field.SetInt(int64(intVal))
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		intVal, err := strconv.Atoi(val)
-		if err != nil {
-			return err
-		}
-		field.SetUint(uint64(intVal))
-	case reflect.Bool:
-		boolVal, err := strconv.ParseBool(val)
-		if err != nil {
-			return err
+func colIndex(header []string, col string, fold bool) int {
+	for i, name := range header {
+		if col == name || (fold && strings.EqualFold(col, name)) {
+			return i
 		}
-		field.SetBool(boolVal)
-
 	}
-	return nil
+	return -1
 }
@@ -1,10 +1,13 @@
 package tsv_test
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/syb-devs/gotools/tsv"
 )
@@ -59,3 +62,254 @@ func TestDecode(t *testing.T) {
 		}
 	}
 }
+
+type level int
+
+func (l *level) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return errors.New("invalid level")
+	}
+	return nil
+}
+
+type record struct {
+	Score    float64   `tsv:"score"`
+	Ratio    *float32  `tsv:"ratio"`
+	Born     time.Time `tsv:"born,layout:2006-01-02"`
+	Seen     time.Time `tsv:"seen"`
+	Tags     []string  `tsv:"tags"`
+	Nums     []int     `tsv:"nums,sep=|"`
+	Level    level     `tsv:"level"`
+	Nickname *string   `tsv:"nick"`
+}
+
+type unsupported struct {
+	Attrs map[string]string `tsv:"attrs"`
+}
+
+func float32Ptr(f float32) *float32 { return &f }
+func stringPtr(s string) *string    { return &s }
+
+var decodeTypesTests = []struct {
+	in   string
+	sep  string
+	dest interface{}
+	out  interface{}
+	err  error
+}{
+	{
+		in: `score	ratio	born	seen	tags	nums	level	nick
+12.5	0.25	1980-05-17	2015-03-01T10:20:30Z	a,b,c	1|2|3	high	johnny`,
+		dest: &record{},
+		out: &record{
+			Score:    12.5,
+			Ratio:    float32Ptr(0.25),
+			Born:     time.Date(1980, 5, 17, 0, 0, 0, 0, time.UTC),
+			Seen:     time.Date(2015, 3, 1, 10, 20, 30, 0, time.UTC),
+			Tags:     []string{"a", "b", "c"},
+			Nums:     []int{1, 2, 3},
+			Level:    2,
+			Nickname: stringPtr("johnny"),
+		},
+	},
+	{
+		in: `tags	level
+a;b	low`,
+		sep:  ";",
+		dest: &record{},
+		out:  &record{Tags: []string{"a", "b"}, Level: 1},
+	},
+	{
+		in: `attrs
+foo`,
+		dest: &unsupported{},
+		out:  &unsupported{},
+		err:  tsv.ErrUnsuportedFieldType,
+	},
+}
+
+func TestDecodeTypes(t *testing.T) {
+	for i, test := range decodeTypesTests {
+		r := tsv.NewReader(strings.NewReader(test.in))
+		r.SliceSep = test.sep
+		r.ReadHeader()
+		r.Next()
+		err := r.Decode(test.dest)
+		if err != test.err {
+			t.Errorf("#%d: error mismatch\nhave %#+v\nwant %#+v", i, err, test.err)
+		}
+		if !reflect.DeepEqual(test.dest, test.out) {
+			t.Errorf("#%d: mismatch\nhave %#+v\nwant %#+v", i, test.dest, test.out)
+		}
+	}
+}
+
+// benchRows returns a TSV document with a header and n rows of report data
+func benchRows(n int) string {
+	var b strings.Builder
+	b.WriteString("ID\ttitle\tscore\tweight\tsmall\tpassed\tday\tcreated\tlabels\tpoints\traw\tgrade\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "%d\treport %d\t%d.5\t0.25\t%d\ttrue\t24/12/2015\t2015-12-24T18:30:05Z\ta|b|c\t1,2.5,-3\traw\tgrade-A\n", i, i, i, i%256)
+	}
+	return b.String()
+}
+
+func benchmarkDecode(b *testing.B, dest func() interface{}) {
+	r := tsv.NewReader(strings.NewReader(benchRows(b.N)))
+	r.ReadHeader()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for r.Next() {
+		if err := r.Decode(dest()); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if r.Err() != nil {
+		b.Fatal(r.Err())
+	}
+}
+
+// BenchmarkDecode measures the cost of decoding one row, allocations included
+func BenchmarkDecode(b *testing.B) {
+	dest := &report{}
+	benchmarkDecode(b, func() interface{} { return dest })
+}
+
+// BenchmarkDecodeNew measures decoding one row into a freshly allocated struct
+func BenchmarkDecodeNew(b *testing.B) {
+	benchmarkDecode(b, func() interface{} { return &report{} })
+}
+
+// BenchmarkRead measures the raw reading cost of one row, as a baseline for the decoding benchmarks
+func BenchmarkRead(b *testing.B) {
+	r := tsv.NewReader(strings.NewReader(benchRows(b.N)))
+	r.ReadHeader()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for r.Next() {
+	}
+}
+
+type address struct {
+	Street string `tsv:"street"`
+	City   string `tsv:"city"`
+}
+
+type audit struct {
+	CreatedBy string `tsv:"created_by"`
+	Name      string `tsv:"audit_name"`
+}
+
+type Meta struct {
+	Source string `tsv:"source"`
+}
+
+type customer struct {
+	audit
+	*Meta
+	Name     string   `tsv:"name"`
+	Address  address  `tsv:"address"`
+	Billing  *address `tsv:"billing"`
+	Shipping address  `tsv:",prefix:ship_"`
+	Other    address
+}
+
+const customers = `name	created_by	audit_name	source	address.street	address.city	billing.city	ship_street	ship_city	street
+john	admin	import	crm	1 Main St	Springfield	Shelbyville	2 Side St	Ogdenville	ignored`
+
+func TestDecodeNested(t *testing.T) {
+	r := tsv.NewReader(strings.NewReader(customers))
+	r.ReadHeader()
+	r.Next()
+	have := &customer{}
+	if err := r.Decode(have); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := &customer{
+		audit:    audit{CreatedBy: "admin", Name: "import"},
+		Meta:     &Meta{Source: "crm"},
+		Name:     "john",
+		Address:  address{Street: "1 Main St", City: "Springfield"},
+		Billing:  &address{City: "Shelbyville"},
+		Shipping: address{Street: "2 Side St", City: "Ogdenville"},
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("mismatch\nhave %#+v\nwant %#+v", have, want)
+	}
+}
+
+type ambiguous struct {
+	address
+	Other struct {
+		City string `tsv:"city"`
+	} `tsv:",prefix:"`
+	City string `tsv:"city"`
+}
+
+func TestDecodeNestedDominance(t *testing.T) {
+	r := tsv.NewReader(strings.NewReader("street\tcity\nmain\tspringfield"))
+	r.ReadHeader()
+	r.Next()
+	have := &ambiguous{}
+	if err := r.Decode(have); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := &ambiguous{address: address{Street: "main"}, City: "springfield"}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("mismatch\nhave %#+v\nwant %#+v", have, want)
+	}
+}
+
+type defaults struct {
+	Name   string   `tsv:"name,default=unknown"`
+	Age    int      `tsv:"age,default=18"`
+	Score  *float64 `tsv:"score"`
+	Level  *int     `tsv:"level,default=1"`
+	Active bool     `tsv:"active,omitempty"`
+	Tags   []string `tsv:"tags,omitempty"`
+}
+
+func intPtr(i int) *int { return &i }
+
+var emptyTests = []struct {
+	policy tsv.EmptyPolicy
+	out    *defaults
+	err    error
+}{
+	{
+		policy: tsv.EmptyDefault,
+		out:    &defaults{Name: "unknown", Age: 18, Level: intPtr(1)},
+	},
+	{
+		policy: tsv.EmptyZero,
+		out:    &defaults{},
+	},
+	{
+		policy: tsv.EmptyError,
+		out:    &defaults{},
+		err:    tsv.ErrEmptyValue,
+	},
+}
+
+func TestDecodeEmpty(t *testing.T) {
+	for i, test := range emptyTests {
+		r := tsv.NewReader(strings.NewReader("name\tage\tscore\tlevel\tactive\ttags\n\t\t\t\t\t"))
+		r.EmptyPolicy = test.policy
+		r.ReadHeader()
+		r.Next()
+		// a previously decoded row must not leak into the empty cells
+		have := &defaults{"previous", 99, new(float64), intPtr(5), true, []string{"x"}}
+		err := r.Decode(have)
+		if !errors.Is(err, test.err) {
+			t.Errorf("#%d: error mismatch\nhave %#+v\nwant %#+v", i, err, test.err)
+		}
+		if test.err == nil && !reflect.DeepEqual(have, test.out) {
+			t.Errorf("#%d: mismatch\nhave %#+v\nwant %#+v", i, have, test.out)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package tsv
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// Writer writes TSV data
+type Writer struct {
+	// SliceSep is the separator used to join slice fields that don't set
+	// a sep tag option. If empty, DefaultSliceSep is used. It must be set
+	// before the first call to Encode
+	SliceSep string
+
+	// Converters holds the custom converters used by Encode. If nil,
+	// DefaultConverters is used. It must be set before the first call to Encode
+	Converters *Converters
+
+	w      *csv.Writer
+	header []string
+	plans  plans
+}
+
+// NewWriter returns a new TSV Writer that writes to w
+func NewWriter(w io.Writer) *Writer {
+	cw := csv.NewWriter(w)
+	cw.Comma = '\t'
+	return &Writer{
+		w: cw,
+	}
+}
+
+// Write writes one record to w
+func (w *Writer) Write(record []string) error {
+	return w.w.Write(record)
+}
+
+// WriteHeader writes the header row and stores it as the header definition used by Encode
+func (w *Writer) WriteHeader(header []string) error {
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	w.header = header
+	w.plans = nil
+	return nil
+}
+
+// Encode writes the src struct as a TSV row, mapping its fields to columns
+// using the same tags understood by Reader.Decode. Zero values of fields with
+// the omitempty tag option are written as empty cells. If no header has been
+// written yet, one is derived from the struct tags and written first
+func (w *Writer) Encode(src interface{}) error {
+	v, err := structValue(src)
+	if err != nil {
+		return err
+	}
+	if w.header == nil {
+		specs, err := fieldSpecs(v.Type())
+		if err != nil {
+			return err
+		}
+		if err = w.WriteHeader(structHeader(specs)); err != nil {
+			return err
+		}
+	}
+	if w.plans == nil {
+		w.plans = make(plans)
+	}
+	pl, err := w.plans.get(v.Type(), w.header, planConfig{sliceSep: w.SliceSep, convs: w.Converters})
+	if err != nil {
+		return err
+	}
+
+	row := make([]string, len(w.header))
+	for _, cf := range pl.fields {
+		if cf.col >= len(row) {
+			continue
+		}
+		f := fieldByIndex(v, cf.index, false)
+		if !f.IsValid() || (cf.omitempty && f.IsZero()) {
+			continue
+		}
+		if row[cf.col], err = formatVal(f, cf.opts); err != nil {
+			return err
+		}
+	}
+	return w.Write(row)
+}
+
+// Flush writes any buffered data to the underlying io.Writer
+func (w *Writer) Flush() {
+	w.w.Flush()
+}
+
+// Error reports any error that has occurred during a previous Write or Flush
+func (w *Writer) Error() error {
+	return w.w.Error()
+}
+
+// structHeader builds a header row from the struct tags. Fields mapped by
+// column index keep their position and are named after the struct field,
+// fields mapped by name fill the remaining positions in declaration order
+func structHeader(specs []fieldSpec) []string {
+	var header, named []string
+	var used []bool
+	for _, spec := range specs {
+		if spec.tag.name != "" {
+			named = append(named, spec.tag.name)
+			continue
+		}
+		if spec.tag.col < 0 {
+			continue
+		}
+		for len(header) <= spec.tag.col {
+			header = append(header, "")
+			used = append(used, false)
+		}
+		header[spec.tag.col] = spec.name
+		used[spec.tag.col] = true
+	}
+
+	i := 0
+	for _, name := range named {
+		for i < len(used) && used[i] {
+			i++
+		}
+		if i < len(header) {
+			header[i] = name
+			used[i] = true
+			continue
+		}
+		header = append(header, name)
+		used = append(used, true)
+	}
+	return header
+}
@@ -0,0 +1,215 @@
+package tsv_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/syb-devs/gotools/tsv"
+)
+
+type grade string
+
+func (g grade) MarshalText() ([]byte, error) {
+	return []byte("grade-" + g), nil
+}
+
+func (g *grade) UnmarshalText(text []byte) error {
+	if !bytes.HasPrefix(text, []byte("grade-")) {
+		return errors.New("invalid grade")
+	}
+	*g = grade(text[len("grade-"):])
+	return nil
+}
+
+// stars marshals as asterisks with pointer receivers, which must also be used for
+// values encoded by value
+type stars int
+
+func (l *stars) MarshalText() ([]byte, error) {
+	return []byte(strings.Repeat("*", int(*l))), nil
+}
+
+func (l *stars) UnmarshalText(text []byte) error {
+	*l = stars(len(text))
+	return nil
+}
+
+type event struct {
+	Name  string     `tsv:"name"`
+	At    time.Time  `tsv:"at"`
+	Until *time.Time `tsv:"until"`
+	Count *int       `tsv:"count"`
+	Level stars      `tsv:"level"`
+}
+
+type report struct {
+	ID      int64     `tsv:",col:0"`
+	Title   string    `tsv:"title"`
+	Score   float64   `tsv:"score"`
+	Weight  *float32  `tsv:"weight"`
+	Small   uint8     `tsv:"small"`
+	Passed  bool      `tsv:"passed"`
+	Day     time.Time `tsv:"day,layout:02/01/2006"`
+	Created time.Time `tsv:"created"`
+	Labels  []string  `tsv:"labels,sep:|"`
+	Points  []float64 `tsv:"points"`
+	Raw     []byte    `tsv:"raw"`
+	Grade   grade     `tsv:"grade"`
+	Skipped string    `tsv:"-"`
+}
+
+var encodeTests = []struct {
+	in  interface{}
+	out string
+}{
+	{
+		in:  &user{"john doe", 35, -10, true, "ignored"},
+		out: "name\tage\trange\tActive\njohn doe\t35\t-10\ttrue\n",
+	},
+	{
+		in: report{
+			ID:     7,
+			Title:  "quarterly",
+			Score:  0.5,
+			Day:    time.Date(2015, 1, 31, 0, 0, 0, 0, time.UTC),
+			Labels: []string{"a", "b"},
+			Grade:  "A",
+		},
+		out: "ID\ttitle\tscore\tweight\tsmall\tpassed\tday\tcreated\tlabels\tpoints\traw\tgrade\n" +
+			"7\tquarterly\t0.5\t\t0\tfalse\t31/01/2015\t0001-01-01T00:00:00Z\ta|b\t\t\tgrade-A\n",
+	},
+}
+
+func TestEncodeNestedHeader(t *testing.T) {
+	var buf bytes.Buffer
+	w := tsv.NewWriter(&buf)
+	if err := w.Encode(&customer{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w.Flush()
+	header := strings.SplitN(buf.String(), "\n", 2)[0]
+	want := "created_by\taudit_name\tsource\tname\taddress.street\taddress.city\tbilling.street\tbilling.city\tship_street\tship_city"
+	if header != want {
+		t.Errorf("header mismatch\nhave %q\nwant %q", header, want)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	for i, test := range encodeTests {
+		var buf bytes.Buffer
+		w := tsv.NewWriter(&buf)
+		if err := w.Encode(test.in); err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+		}
+		w.Flush()
+		if buf.String() != test.out {
+			t.Errorf("#%d: mismatch\nhave %q\nwant %q", i, buf.String(), test.out)
+		}
+	}
+}
+
+var roundTripTests = []interface{}{
+	&user{"john doe", 35, -10, true, ""},
+	&user{"jane doe", 0, 0, false, ""},
+	&report{
+		ID:      1 << 40,
+		Title:   "with \"quotes\" and\ttabs",
+		Score:   -1234.5678,
+		Weight:  float32Ptr(0.125),
+		Small:   255,
+		Passed:  true,
+		Day:     time.Date(2015, 12, 24, 0, 0, 0, 0, time.UTC),
+		Created: time.Date(2015, 12, 24, 18, 30, 5, 0, time.UTC),
+		Labels:  []string{"x", "y", "z"},
+		Points:  []float64{1, 2.5, -3},
+		Raw:     []byte("raw bytes"),
+		Grade:   "B+",
+	},
+	&report{ID: 2, Created: time.Date(2015, 12, 24, 18, 30, 5, 123456789, time.UTC)},
+	&report{ID: 3, Labels: []string{"x,y", "z;w"}, Points: []float64{0.5}},
+	&event{Name: "launch", At: time.Date(2020, 1, 2, 3, 4, 5, 6, time.FixedZone("", 3600)), Level: 3},
+	&event{Name: "empty"},
+	&customer{
+		audit:    audit{CreatedBy: "admin", Name: "import"},
+		Meta:     &Meta{Source: "crm"},
+		Name:     "john",
+		Address:  address{Street: "1 Main St", City: "Springfield"},
+		Billing:  &address{Street: "3 Other St", City: "Shelbyville"},
+		Shipping: address{Street: "2 Side St", City: "Ogdenville"},
+	},
+	&customer{audit: audit{CreatedBy: "admin"}, Name: "jane", Address: address{City: "Springfield"}},
+}
+
+func TestRoundTrip(t *testing.T) {
+	for i, in := range roundTripTests {
+		var buf bytes.Buffer
+		w := tsv.NewWriter(&buf)
+		if err := w.Encode(in); err != nil {
+			t.Fatalf("#%d: encoding: %v", i, err)
+		}
+		w.Flush()
+
+		out := reflect.New(reflect.TypeOf(in).Elem()).Interface()
+		r := tsv.NewReader(&buf)
+		r.ReadHeader()
+		r.Next()
+		if err := r.Decode(out); err != nil {
+			t.Fatalf("#%d: decoding: %v", i, err)
+		}
+		if !reflect.DeepEqual(in, out) {
+			t.Errorf("#%d: mismatch\nhave %#+v\nwant %#+v", i, out, in)
+		}
+	}
+}
+
+func TestEncodeSliceSep(t *testing.T) {
+	tests := []interface{}{
+		&report{Labels: []string{"x|y", "z"}},
+		&struct {
+			Tags []string `tsv:"tags"`
+		}{[]string{"x,y", "z"}},
+	}
+	for i, in := range tests {
+		w := tsv.NewWriter(&bytes.Buffer{})
+		if err := w.Encode(in); !errors.Is(err, tsv.ErrSliceSep) {
+			t.Errorf("#%d: expecting slice separator error, got %v", i, err)
+		}
+	}
+}
+
+func TestEncodeByValue(t *testing.T) {
+	count := 0
+	ev := event{Name: "launch", At: time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC), Count: &count, Level: 2}
+	var buf bytes.Buffer
+	w := tsv.NewWriter(&buf)
+	if err := w.Encode(ev); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w.Flush()
+	want := "name\tat\tuntil\tcount\tlevel\nlaunch\t2020-01-02T03:04:05.000000006Z\t\t0\t**\n"
+	if buf.String() != want {
+		t.Errorf("mismatch\nhave %q\nwant %q", buf.String(), want)
+	}
+}
+
+type optional struct {
+	Name  string  `tsv:"name"`
+	Count int     `tsv:"count,omitempty"`
+	Ratio float64 `tsv:"ratio"`
+}
+
+func TestEncodeOmitEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	w := tsv.NewWriter(&buf)
+	w.Encode(optional{})
+	w.Encode(optional{"x", 3, 0.5})
+	w.Flush()
+	want := "name\tcount\tratio\n\t\t0\nx\t3\t0.5\n"
+	if buf.String() != want {
+		t.Errorf("mismatch\nhave %q\nwant %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,401 @@
+/*
+Package validate checks struct fields against the rules of their validate tag.
+
+Rules are comma separated, and their parameters follow an = or a colon:
+
+	Name  string  `validate:"required,min=2,max=50"`      // length, in characters
+	Age   int     `validate:"min=18,max=130"`             // numeric range
+	Role  string  `validate:"oneof=admin|editor|viewer"`  // allowed values
+	Email string  `validate:"omitempty,regex=^[^@]+@[^@]+$"`
+	Price string  `validate:"number,min=0"`               // numeric string
+
+The min and max rules bound the length of strings, slices and maps, and the
+value of numbers. The number rule makes strings hold a number, which min and
+max then bound by value, as they do for numeric fields. The regex rule takes
+the rest of the tag, commas included, so it must be the last one. Nil
+pointers only fail the required rule, and omitempty skips the rules of zero
+values.
+
+The same rules are used by the tsv package after decoding each row, and by
+the form package.
+*/
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/syb-devs/gotools/structinfo"
+)
+
+var (
+	// ErrInvalidRule is returned for malformed or unknown rules
+	ErrInvalidRule = errors.New("invalid validation rule")
+
+	// ErrRequired is returned for zero values of required fields
+	ErrRequired = errors.New("value is required")
+
+	// ErrMin is returned for values or lengths below the minimum
+	ErrMin = errors.New("below the minimum")
+
+	// ErrMax is returned for values or lengths above the maximum
+	ErrMax = errors.New("above the maximum")
+
+	// ErrPattern is returned for strings not matching the regex rule
+	ErrPattern = errors.New("does not match")
+
+	// ErrOneOf is returned for values not listed in the oneof rule
+	ErrOneOf = errors.New("not one of")
+
+	// ErrNumber is returned for strings that are not numbers with the number rule
+	ErrNumber = errors.New("not a number")
+)
+
+// Rule is a single validation rule
+type Rule struct {
+	Name  string // required, omitempty, number, min, max, oneof or regex
+	Param string
+
+	num    float64
+	re     *regexp.Regexp
+	values []string
+}
+
+// Rules are the rules of a validate tag, in order
+type Rules []Rule
+
+// Parse parses the rules of a validate tag
+func Parse(tag string) (Rules, error) {
+	var rules Rules
+	for tag != "" {
+		var item string
+		item, tag, _ = strings.Cut(tag, ",")
+		name, param := item, ""
+		if i := strings.IndexAny(item, ":="); i >= 0 {
+			name, param = item[:i], item[i+1:]
+		}
+		name = strings.TrimSpace(name)
+		if name == "regex" && tag != "" {
+			param, tag = param+","+tag, ""
+		}
+		if name == "" {
+			continue
+		}
+		rule, err := newRule(name, param)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// MustParse is like Parse but panics if the tag is invalid
+func MustParse(tag string) Rules {
+	rules, err := Parse(tag)
+	if err != nil {
+		panic(err)
+	}
+	return rules
+}
+
+func newRule(name, param string) (Rule, error) {
+	rule := Rule{Name: name, Param: param}
+	var err error
+	switch name {
+	case "required", "omitempty", "number":
+		if param != "" {
+			err = ErrInvalidRule
+		}
+	case "min", "max":
+		rule.num, err = strconv.ParseFloat(param, 64)
+	case "oneof":
+		rule.values = strings.Split(param, "|")
+	case "regex":
+		rule.re, err = regexp.Compile(param)
+	default:
+		err = ErrInvalidRule
+	}
+	if err != nil {
+		return rule, fmt.Errorf("%w %q: %v", ErrInvalidRule, name+"="+param, err)
+	}
+	return rule, nil
+}
+
+// Validate checks v against the rules, returning the error of the first one it breaks
+func (rules Rules) Validate(v reflect.Value) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			if rules.has("required") {
+				return ErrRequired
+			}
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() || (v.IsZero() && rules.has("omitempty")) {
+		return nil
+	}
+	// the bounds of number strings apply to their value
+	bounded := v
+	if v.Kind() == reflect.String && rules.has("number") {
+		f, err := strconv.ParseFloat(strings.TrimSpace(v.String()), 64)
+		if err != nil {
+			bounded = reflect.Value{}
+		} else {
+			bounded = reflect.ValueOf(f)
+		}
+	}
+	for _, rule := range rules {
+		target := v
+		if rule.Name == "min" || rule.Name == "max" {
+			target = bounded
+		}
+		if err := rule.check(target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateString checks the string s against the rules
+func (rules Rules) ValidateString(s string) error {
+	return rules.Validate(reflect.ValueOf(s))
+}
+
+func (rules Rules) has(name string) bool {
+	for _, rule := range rules {
+		if rule.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (rule Rule) check(v reflect.Value) error {
+	switch rule.Name {
+	case "required":
+		if v.IsZero() {
+			return ErrRequired
+		}
+	case "number":
+		switch v.Kind() {
+		case reflect.String:
+			if _, err := strconv.ParseFloat(strings.TrimSpace(v.String()), 64); err != nil {
+				return fmt.Errorf("%q is %w", v.String(), ErrNumber)
+			}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+			reflect.Float32, reflect.Float64:
+		default:
+			return rule.unsupported(v)
+		}
+	case "min", "max":
+		if !v.IsValid() {
+			return ErrNumber
+		}
+		return rule.checkBound(v)
+	case "oneof":
+		s := format(v)
+		for _, allowed := range rule.values {
+			if s == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w %s", ErrOneOf, strings.Join(rule.values, ", "))
+	case "regex":
+		if v.Kind() != reflect.String {
+			return rule.unsupported(v)
+		}
+		if !rule.re.MatchString(v.String()) {
+			return fmt.Errorf("%w %s", ErrPattern, rule.Param)
+		}
+	}
+	return nil
+}
+
+func (rule Rule) checkBound(v reflect.Value) error {
+	what := "value"
+	var n float64
+	switch v.Kind() {
+	case reflect.String:
+		what, n = "length", float64(utf8.RuneCountInString(v.String()))
+	case reflect.Slice, reflect.Map, reflect.Array:
+		what, n = "length", float64(v.Len())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = v.Float()
+	default:
+		return rule.unsupported(v)
+	}
+	if rule.Name == "min" && n < rule.num {
+		return fmt.Errorf("%s %s is %w of %s", what, format(reflect.ValueOf(n)), ErrMin, rule.Param)
+	}
+	if rule.Name == "max" && n > rule.num {
+		return fmt.Errorf("%s %s is %w of %s", what, format(reflect.ValueOf(n)), ErrMax, rule.Param)
+	}
+	return nil
+}
+
+func (rule Rule) unsupported(v reflect.Value) error {
+	return fmt.Errorf("%w: %s does not apply to %s", ErrInvalidRule, rule.Name, v.Type())
+}
+
+// format returns the string form of v used by the oneof rule
+func format(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits())
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// FieldError reports a struct field breaking one of its rules
+type FieldError struct {
+	Field string // Name of the field, dotted for nested structs
+	Value interface{}
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("field %s: %v", e.Field, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Errors is the list of errors found validating a struct
+type Errors []*FieldError
+
+func (l Errors) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	}
+	msgs := make([]string, len(l))
+	for i, err := range l {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d errors: %s", len(l), strings.Join(msgs, "; "))
+}
+
+// fieldRules holds the rules of a struct field. Struct fields without rules
+// of their own are validated field by field
+type fieldRules struct {
+	index  int
+	name   string // empty for embedded structs, whose fields are promoted
+	rules  Rules
+	nested bool
+}
+
+// typeRules caches the field rules of every struct type seen so far
+var typeRules sync.Map
+
+func rulesOf(t reflect.Type) ([]fieldRules, error) {
+	if rules, ok := typeRules.Load(t); ok {
+		return rules.([]fieldRules), nil
+	}
+	info, err := structinfo.ExtractType(t, "validate")
+	if err != nil {
+		return nil, err
+	}
+	var fields []fieldRules
+	for _, fi := range info.Fields.All {
+		// the fields of unexported embedded structs are promoted, so they're validated too
+		if fi.Tag == "-" || (!fi.Exported && !fi.Anonymous) {
+			continue
+		}
+		rules, err := Parse(fi.Tag)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", fi.Name, err)
+		}
+		ft := t.Field(fi.Index).Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		nested := ft.Kind() == reflect.Struct && rules == nil
+		if !fi.Exported && !nested {
+			continue
+		}
+		name := fi.Name
+		if nested && fi.Anonymous {
+			name = ""
+		}
+		if rules != nil || nested {
+			fields = append(fields, fieldRules{index: fi.Index, name: name, rules: rules, nested: nested})
+		}
+	}
+	typeRules.Store(t, fields)
+	return fields, nil
+}
+
+// Struct checks the fields of the struct v against their rules, returning
+// an Errors list with every broken rule. Nested structs are checked too
+func Struct(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return structinfo.ErrInvalidType
+	}
+	var errs Errors
+	if err := validateStruct(rv, "", &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateStruct(v reflect.Value, prefix string, errs *Errors) error {
+	fields, err := rulesOf(v.Type())
+	if err != nil {
+		return err
+	}
+	for _, fr := range fields {
+		f := v.Field(fr.index)
+		if fr.nested {
+			for f.Kind() == reflect.Ptr && !f.IsNil() {
+				f = f.Elem()
+			}
+			if f.Kind() == reflect.Struct {
+				nestedPrefix := prefix
+				if fr.name != "" {
+					nestedPrefix += fr.name + "."
+				}
+				if err := validateStruct(f, nestedPrefix, errs); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := fr.rules.Validate(f); err != nil {
+			if errors.Is(err, ErrInvalidRule) {
+				return fmt.Errorf("field %s: %w", prefix+fr.name, err)
+			}
+			ferr := &FieldError{Field: prefix + fr.name, Err: err}
+			if f.CanInterface() {
+				ferr.Value = f.Interface()
+			}
+			*errs = append(*errs, ferr)
+		}
+	}
+	return nil
+}
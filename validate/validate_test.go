@@ -0,0 +1,119 @@
+package validate_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/syb-devs/gotools/validate"
+)
+
+var ruleTests = []struct {
+	tag   string
+	value interface{}
+	err   error
+	msg   string
+}{
+	{"required", "", validate.ErrRequired, "value is required"},
+	{"required", "john", nil, ""},
+	{"required", (*int)(nil), validate.ErrRequired, "value is required"},
+	{"min=2", (*int)(nil), nil, ""},
+	{"min=3,max=5", "jo", validate.ErrMin, "length 2 is below the minimum of 3"},
+	{"min=3,max=5", "josé", nil, ""},
+	{"min:3,max:5", "johnny", validate.ErrMax, "length 6 is above the maximum of 5"},
+	{"min=18,max=130", 17, validate.ErrMin, "value 17 is below the minimum of 18"},
+	{"min=18,max=130", uint8(200), validate.ErrMax, "value 200 is above the maximum of 130"},
+	{"min=0.5", 0.25, validate.ErrMin, "value 0.25 is below the minimum of 0.5"},
+	{"max=2", []string{"a", "b", "c"}, validate.ErrMax, "length 3 is above the maximum of 2"},
+	{"oneof=admin|editor", "editor", nil, ""},
+	{"oneof=admin|editor", "root", validate.ErrOneOf, "not one of admin, editor"},
+	{"oneof=1|2|3", 4, validate.ErrOneOf, "not one of 1, 2, 3"},
+	{"regex=^[a-z]{2,3}$", "abc", nil, ""},
+	{"regex=^[a-z]{2,3}$", "abcd", validate.ErrPattern, "does not match ^[a-z]{2,3}$"},
+	{"omitempty,min=3", "", nil, ""},
+	{"omitempty,min=3", "ab", validate.ErrMin, "length 2 is below the minimum of 3"},
+	{"regex=^a", 1, validate.ErrInvalidRule, "invalid validation rule: regex does not apply to int"},
+	{"number,min=18", "9", validate.ErrMin, "value 9 is below the minimum of 18"},
+	{"number,min=18,max=130", "18.5", nil, ""},
+	{"number,max=130", "1000", validate.ErrMax, "value 1000 is above the maximum of 130"},
+	{"number,min=18", "abc", validate.ErrNumber, `"abc" is not a number`},
+	{"min=18,number", "abc", validate.ErrNumber, "not a number"},
+	{"required,number", "", validate.ErrRequired, "value is required"},
+	{"number", 12, nil, ""},
+	{"number", true, validate.ErrInvalidRule, "invalid validation rule: number does not apply to bool"},
+}
+
+func TestRules(t *testing.T) {
+	for i, tt := range ruleTests {
+		rules, err := validate.Parse(tt.tag)
+		if err != nil {
+			t.Fatalf("#%d: unexpected error parsing %q: %v", i, tt.tag, err)
+		}
+		err = rules.Validate(reflect.ValueOf(tt.value))
+		if !errors.Is(err, tt.err) || (err == nil) != (tt.err == nil) {
+			t.Errorf("#%d: error mismatch\nhave %v\nwant %v", i, err, tt.err)
+			continue
+		}
+		if err != nil && err.Error() != tt.msg {
+			t.Errorf("#%d: message mismatch\nhave %q\nwant %q", i, err.Error(), tt.msg)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, tag := range []string{"min=abc", "unknown", "required=1", "regex=[a-"} {
+		if _, err := validate.Parse(tag); !errors.Is(err, validate.ErrInvalidRule) {
+			t.Errorf("%q: expecting an invalid rule error, got %v", tag, err)
+		}
+	}
+}
+
+type address struct {
+	City string `validate:"required"`
+	Zip  string `validate:"regex=^[0-9]{5}$"`
+}
+
+type person struct {
+	address
+	Name    string `validate:"required,min=2"`
+	Age     int    `validate:"min=0,max=130"`
+	Role    string `validate:"oneof=admin|editor"`
+	Work    *address
+	Tags    []string `validate:"max=2"`
+	ignored string   `validate:"required"`
+}
+
+func TestStruct(t *testing.T) {
+	p := person{
+		address: address{City: "Madrid", Zip: "28001"},
+		Name:    "John",
+		Age:     35,
+		Role:    "admin",
+	}
+	if err := validate.Struct(&p); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	p.Zip = "280"
+	p.Age = 140
+	p.Work = &address{Zip: "08001"}
+	err := validate.Struct(p)
+	errs, ok := err.(validate.Errors)
+	if !ok {
+		t.Fatalf("expecting validate.Errors, got %#v", err)
+	}
+	var fields []string
+	for _, err := range errs {
+		fields = append(fields, err.Field)
+	}
+	if want := []string{"Zip", "Age", "Work.City"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("fields mismatch\nhave %v\nwant %v", fields, want)
+	}
+	if want := "field Age: value 140 is above the maximum of 130"; errs[1].Error() != want {
+		t.Errorf("message mismatch\nhave %q\nwant %q", errs[1].Error(), want)
+	}
+
+	if err := validate.Struct(1); err == nil {
+		t.Error("expecting an error validating a non struct")
+	}
+}